@@ -1 +1,39 @@
-export const baseApiUrl = '/api';
\ No newline at end of file
+export const baseApiUrl = '/api';
+
+let refreshing: Promise<boolean> | undefined;
+
+// The refresh token can only be used once, so concurrent callers share one request.
+export function refreshAccessToken(): Promise<boolean> {
+  if (!refreshing) {
+    refreshing = fetch(baseApiUrl + '/auth/refresh', {
+      method: 'POST',
+      credentials: 'include',
+    })
+      .then((res) => res.ok)
+      .catch(() => false)
+      .finally(() => {
+        refreshing = undefined;
+      });
+  }
+  return refreshing;
+}
+
+async function isTokenExpired(res: Response) {
+  if (res.status !== 401) return false;
+  try {
+    const body = await res.clone().json();
+    return body?.code === 'TOKEN_EXPIRED';
+  } catch {
+    return false;
+  }
+}
+
+// apiFetch calls the API like fetch. When the access token has expired, it is refreshed
+// once and the request retried, so an expired token does not interrupt the user.
+export async function apiFetch(input: string, init?: RequestInit): Promise<Response> {
+  const res = await fetch(input, init);
+  if (!(await isTokenExpired(res)) || !(await refreshAccessToken())) {
+    return res;
+  }
+  return await fetch(input, init);
+}
@@ -2,9 +2,14 @@ import { useState, createContext, useEffect } from "react";
 import Cookies from "js-cookie";
 import { useQuery } from "@tanstack/react-query";
 import { getUserRoleApi } from "./authApi";
+import { refreshAccessToken } from "../../common/baseApi";
 import { queryClient } from "../../common/query";
 import Loading from "../layout/Loading";
 
+// The access token expires after 15 minutes, so it is renewed a bit earlier. Timers of
+// background tabs can be delayed; apiFetch then refreshes the token when a request fails.
+const tokenRefreshInterval = 10 * 60 * 1000;
+
 export type UserContextType = {
   username?: string;
   role?: string;
@@ -29,6 +34,7 @@ export function UserProvider({ children }: { children: React.ReactNode }) {
     queryKey: ["role", username],
     queryFn: async () => {
       if (username) {
+        await refreshAccessToken();
         return await getUserRoleApi();
       }
       return { role: "" };
@@ -51,6 +57,15 @@ export function UserProvider({ children }: { children: React.ReactNode }) {
     };
   }, [username]);
 
+  useEffect(() => {
+    if (!username) return;
+    const timer = window.setInterval(refreshAccessToken, tokenRefreshInterval);
+
+    return () => {
+      window.clearInterval(timer);
+    };
+  }, [username]);
+
   if (isLoading) return <Loading />;
 
   return (
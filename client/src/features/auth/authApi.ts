@@ -1,5 +1,5 @@
 import { base64, fromBase64 } from "../../common/base64";
-import { baseApiUrl } from "../../common/baseApi";
+import { apiFetch, baseApiUrl } from "../../common/baseApi";
 import { getErrorMessage } from "../../common/errorMessage";
 
 export interface LoginRequest {
@@ -83,7 +83,7 @@ export async function changePasswordApi(request: ChangePasswordRequest) {
     request.timestamp
   );
 
-  const resp = await fetch(baseApiUrl + "/user/changepassword", {
+  const resp = await apiFetch(baseApiUrl + "/user/changepassword", {
     method: "POST",
     headers: {
       "Content-Type": "application/json",
@@ -106,7 +106,7 @@ interface UserRoleResponse {
 }
 
 export async function getUserRoleApi(): Promise<UserRoleResponse> {
-  const resp = await fetch(baseApiUrl + "/user/role", {
+  const resp = await apiFetch(baseApiUrl + "/user/role", {
     credentials: "include",
   });
   return await resp.json();
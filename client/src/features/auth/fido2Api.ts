@@ -1,4 +1,4 @@
-import { baseApiUrl } from "../../common/baseApi";
+import { apiFetch, baseApiUrl } from "../../common/baseApi";
 
 // Utility functions
 function generateRandomString(length: number): string {
@@ -11,7 +11,7 @@ function generateRandomString(length: number): string {
 }
 
 async function apiRequest(url: string, options: RequestInit): Promise<any> {
-    const response = await fetch(baseApiUrl + url, {
+    const response = await apiFetch(baseApiUrl + url, {
         credentials: 'include',
         headers: {
             'Content-Type': 'application/json',
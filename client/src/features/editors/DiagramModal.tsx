@@ -9,6 +9,7 @@ import { useState } from "react";
 import { uploadDiagram } from "./uploadApi";
 import { NonDeletedExcalidrawElement } from "@excalidraw/excalidraw/element/types";
 import { createPortal } from "react-dom";
+import { apiFetch } from "../../common/baseApi";
 
 interface Diagram {
   elements: NonDeletedExcalidrawElement[];
@@ -32,7 +33,7 @@ export default function DiagramModal({
   const { data } = useQuery<Diagram>({
     queryKey: ["diagram", id],
     queryFn: async () => {
-      const res = await fetch(`/api/editor/diagram/source/${id}`);
+      const res = await apiFetch(`/api/editor/diagram/source/${id}`);
       if (!res.ok) {
         throw new Error("Failed to fetch diagram");
       }
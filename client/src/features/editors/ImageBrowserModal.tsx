@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { createPortal } from 'react-dom';
 import { IconX, IconPhoto, IconLoader, IconSearch, IconFolderOpen } from '@tabler/icons-react';
+import { apiFetch } from '../../common/baseApi';
 
 interface ImageBrowserModalProps {
     onClose: (selectedImageUrl?: string) => void;
@@ -44,7 +45,7 @@ export default function ImageBrowserModal({ onClose }: ImageBrowserModalProps) {
             setError(null);
 
             // First, get list of thumbnail images
-            const response = await fetch('/api/editor/files/list?path=uploads/thumbnails', {
+            const response = await apiFetch('/api/editor/files/list?path=uploads/thumbnails', {
                 credentials: 'include'
             });
 
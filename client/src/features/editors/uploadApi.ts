@@ -1,4 +1,4 @@
-import { baseApiUrl } from "../../common/baseApi";
+import { apiFetch, baseApiUrl } from "../../common/baseApi";
 
 export interface UploadDiagramRequest {
   id: string;
@@ -16,7 +16,7 @@ export interface UploadDiagramResponse {
 export async function uploadDiagram(
   request: UploadDiagramRequest
 ): Promise<UploadDiagramResponse> {
-  const res = await fetch(baseApiUrl + "/editor/diagram/upload", {
+  const res = await apiFetch(baseApiUrl + "/editor/diagram/upload", {
     method: "POST",
     headers: {
       "Content-Type": "application/json",
@@ -38,7 +38,7 @@ export async function uploadImage(
   formData.append("file", file);
   formData.append("id", id);
 
-  const res = await fetch(baseApiUrl + "/editor/image/upload", {
+  const res = await apiFetch(baseApiUrl + "/editor/image/upload", {
     method: "POST",
     body: formData,
   });
@@ -50,7 +50,7 @@ export async function uploadImage(
 }
 
 export async function rebuildThumbnails() {
-  const res = await fetch(baseApiUrl + "/editor/ckeditor/upload/regeneratethumbnail", {
+  const res = await apiFetch(baseApiUrl + "/editor/ckeditor/upload/regeneratethumbnail", {
     method: "POST",
   });
   if (!res.ok) {
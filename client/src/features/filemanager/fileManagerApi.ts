@@ -1,4 +1,4 @@
-import { baseApiUrl } from "../../common/baseApi";
+import { apiFetch, baseApiUrl } from "../../common/baseApi";
 
 export interface FileItem {
     name: string;
@@ -20,7 +20,7 @@ export interface ReadFileResponse {
 }
 
 export async function listFiles(path: string = "/"): Promise<ListFilesResponse> {
-    const res = await fetch(`${baseApiUrl}/editor/files/list?path=${encodeURIComponent(path)}`, {
+    const res = await apiFetch(`${baseApiUrl}/editor/files/list?path=${encodeURIComponent(path)}`, {
         credentials: 'include',
     });
 
@@ -32,7 +32,7 @@ export async function listFiles(path: string = "/"): Promise<ListFilesResponse>
 }
 
 export async function readFile(fileName: string, path: string = "/"): Promise<ReadFileResponse> {
-    const res = await fetch(`${baseApiUrl}/editor/files/read?fileName=${encodeURIComponent(fileName)}&path=${encodeURIComponent(path)}`, {
+    const res = await apiFetch(`${baseApiUrl}/editor/files/read?fileName=${encodeURIComponent(fileName)}&path=${encodeURIComponent(path)}`, {
         credentials: 'include',
     });
 
@@ -44,7 +44,7 @@ export async function readFile(fileName: string, path: string = "/"): Promise<Re
 }
 
 export async function getFileInfo(fileName: string, path: string = "/"): Promise<FileItem> {
-    const res = await fetch(`${baseApiUrl}/editor/files/info?fileName=${encodeURIComponent(fileName)}&path=${encodeURIComponent(path)}`, {
+    const res = await apiFetch(`${baseApiUrl}/editor/files/info?fileName=${encodeURIComponent(fileName)}&path=${encodeURIComponent(path)}`, {
         credentials: 'include',
     });
 
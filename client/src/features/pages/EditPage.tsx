@@ -2,6 +2,8 @@ import { useMutation, useQuery } from "@tanstack/react-query";
 import { useNavigate, useParams } from "react-router-dom";
 import {
   deletePage,
+  DeleteMode,
+  getAllPages,
   getLatestPageRevisionByUrl,
   getPageByUrl,
   PageRequest,
@@ -82,7 +84,8 @@ export default function EditPage() {
   });
 
   const deletePageApi = useMutation({
-    mutationFn: (page: PageRequest) => deletePage(page.id),
+    mutationFn: ({ page, mode }: { page: PageRequest; mode: DeleteMode }) =>
+      deletePage(page.id, mode),
     onSuccess: () => {
       queryClient.removeQueries({ queryKey: ["page", pageId] });
       clearCache();
@@ -94,6 +97,25 @@ export default function EditPage() {
     }
   });
 
+  async function confirmDelete() {
+    if (!confirm(t('Are you sure to delete this page?'))) return;
+    let mode: DeleteMode = 'refuse';
+    try {
+      const pages = await queryClient.fetchQuery({
+        queryKey: ['pages', true],
+        queryFn: getAllPages,
+      });
+      if (pages.some((p) => p.parentId === data.id)) {
+        mode = confirm(t('This page has child pages. Delete them too? Choose Cancel to move them to the parent page instead.'))
+          ? 'cascade'
+          : 'reparent';
+      }
+    } catch {
+      // the server refuses to delete a page with child pages
+    }
+    deletePageApi.mutate({ page: data, mode });
+  }
+
   useEffect(() => {
     if (initialData && !autoSaveData) {
       setData(initialData);
@@ -257,10 +279,7 @@ export default function EditPage() {
               {t('Revert')}
             </button>
             <button
-              onClick={() => {
-                if (confirm(t('Are you sure to delete this page?')))
-                  deletePageApi.mutate(data);
-              }}
+              onClick={confirmDelete}
               className="bg-red-700 hover:bg-red-800 dark:bg-red-600 dark:hover:bg-red-700 text-white w-full box-border rounded-md py-2 px-5 mb-2"
             >
               {t('Delete')}
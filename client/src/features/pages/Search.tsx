@@ -1,13 +1,16 @@
 import { ChangeEvent, useState } from "react";
 import { useTranslation } from "react-i18next";
-import { PageMeta, searchPages } from "./pageApi";
+import { SearchResults, searchPages } from "./pageApi";
 import { IconFidgetSpinner } from "@tabler/icons-react";
 
+const pageSize = 10;
+
 export default function Search() {
     const { t } = useTranslation();
     const [searchQuery, setSearchQuery] = useState('');
     const [activeSearchQuery, setActiveSearchQuery] = useState<string>('');
-    const [searchResults, setSearchResults] = useState<PageMeta[]>();
+    const [searchResults, setSearchResults] = useState<SearchResults>();
+    const [offset, setOffset] = useState(0);
     const [loading, setLoading] = useState(false);
     const [error, setError] = useState<string | null>(null);
 
@@ -25,15 +28,20 @@ export default function Search() {
         if (searchQuery === activeSearchQuery && searchResults) {
             return;
         }
+        await loadResults(searchQuery, 0);
+    };
+
+    const loadResults = async (query: string, newOffset: number) => {
         setLoading(true);
         setError(null);
-        setActiveSearchQuery(searchQuery);
+        setActiveSearchQuery(query);
+        setOffset(newOffset);
         try {
-            const results = await searchPages(searchQuery);
+            const results = await searchPages(query, newOffset, pageSize);
             setSearchResults(results);
         } catch (err) {
             setError(t('Failed to fetch search results'));
-            setSearchResults([]);
+            setSearchResults({ results: [], total: 0 });
         } finally {
             setLoading(false);
         }
@@ -73,13 +81,13 @@ export default function Search() {
                 </section>
             )}
 
-            {searchResults && searchResults.length > 0 && (
+            {searchResults && searchResults.results.length > 0 && (
                 <section>
                     <h2 className="text-lg font-semibold mb-4">
-                        {t("Search Results for")} "{activeSearchQuery}" ({searchResults.length})
+                        {t("Search Results for")} "{activeSearchQuery}" ({searchResults.total})
                     </h2>
                     <div className="space-y-3">
-                        {searchResults.map((page) => (
+                        {searchResults.results.map((page) => (
                             <div key={page.id} className="border-2 border-gray-200 dark:border-gray-700 bg-white dark:bg-gray-800 rounded-md p-4">
                                 <a
                                     href={`/p${page.url}`}
@@ -95,10 +103,31 @@ export default function Search() {
                             </div>
                         ))}
                     </div>
+                    {searchResults.total > pageSize && (
+                        <div className="flex flex-row justify-between items-center mt-4">
+                            <button
+                                onClick={() => loadResults(activeSearchQuery, offset - pageSize)}
+                                disabled={loading || offset === 0}
+                                className="border-2 border-gray-200 dark:border-gray-700 rounded-md py-1 px-4 disabled:opacity-50"
+                            >
+                                {t("Previous")}
+                            </button>
+                            <span className="text-sm text-gray-500 dark:text-gray-400">
+                                {offset + 1}-{offset + searchResults.results.length} / {searchResults.total}
+                            </span>
+                            <button
+                                onClick={() => loadResults(activeSearchQuery, offset + pageSize)}
+                                disabled={loading || offset + pageSize >= searchResults.total}
+                                className="border-2 border-gray-200 dark:border-gray-700 rounded-md py-1 px-4 disabled:opacity-50"
+                            >
+                                {t("Next")}
+                            </button>
+                        </div>
+                    )}
                 </section>
             )}
 
-            {searchQuery && !loading && searchResults?.length === 0 && !error && (
+            {searchQuery && !loading && searchResults?.results.length === 0 && !error && (
                 <section className="flex flex-row">
                     <div className="w-full text-center py-8 text-gray-500 dark:text-gray-400 border-2 border-gray-200 dark:border-gray-700 rounded-md">
                         {t("No pages found for")} "{activeSearchQuery}"
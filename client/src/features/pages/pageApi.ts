@@ -1,4 +1,4 @@
-import { baseApiUrl } from "../../common/baseApi";
+import { apiFetch, baseApiUrl } from "../../common/baseApi";
 import { getErrorMessage } from "../../common/errorMessage";
 
 export interface PageRequest {
@@ -45,36 +45,51 @@ export interface PageMeta {
 }
 
 export function getPage(pageId: string): Promise<PageResponse> {
-    return fetch(baseApiUrl + `/page/${pageId}`).then((res) => res.json());
+    return apiFetch(baseApiUrl + `/page/${pageId}`).then((res) => res.json());
 }
 
 export async function getPageByUrl(url: string): Promise<PageResponse> {
-    const res = await fetch(baseApiUrl + `/page/url/${url}`,
+    const res = await apiFetch(baseApiUrl + `/page/url/${url}`,
         { credentials: 'include' }
     );
     return await res.json();
 }
 
 export async function getLatestPageRevisionByUrl(id: number): Promise<RevisionPageResponse> {
-    const res = await fetch(baseApiUrl + `/editor/pagerevision/${id}`,
+    const res = await apiFetch(baseApiUrl + `/editor/pagerevision/${id}`,
         { credentials: 'include' }
     );
     return await res.json();
 }
 
 export async function getAllPages(): Promise<PageMeta[]> {
-    const res = await fetch(baseApiUrl + `/pages/listall`, {
+    const res = await apiFetch(baseApiUrl + `/pages/listall`, {
         credentials: 'include',
     });
     return await res.json();
 }
 
 export function getRootPages(): Promise<PageMeta[]> {
-    return fetch(baseApiUrl + `/pages/list`).then((res) => res.json());
+    return apiFetch(baseApiUrl + `/pages/list`).then((res) => res.json());
 }
 
-export async function searchPages(query: string): Promise<PageMeta[]> {
-    const res = await fetch(baseApiUrl + `/pages/search?q=${encodeURIComponent(query)}`, {
+export interface SearchResult {
+    id: number;
+    title: string;
+    url: string;
+    shortDesc: string;
+    score: number;
+    snippet: string;
+    attachments?: string[];
+}
+
+export interface SearchResults {
+    results: SearchResult[];
+    total: number;
+}
+
+export async function searchPages(query: string, offset: number, limit: number): Promise<SearchResults> {
+    const res = await apiFetch(baseApiUrl + `/pages/search?q=${encodeURIComponent(query)}&offset=${offset}&limit=${limit}`, {
         credentials: 'include',
     });
     if (res.status >= 400) {
@@ -84,7 +99,7 @@ export async function searchPages(query: string): Promise<PageMeta[]> {
 }
 
 export async function createPage(page: PageRequest) {
-    const res = await fetch(baseApiUrl + `/editor/pages`, {
+    const res = await apiFetch(baseApiUrl + `/editor/pages`, {
         method: 'POST',
         headers: {
             'Content-Type': 'application/json',
@@ -100,7 +115,7 @@ export async function createPage(page: PageRequest) {
 }
 
 export async function updatePage(page: PageRequest) {
-    const res = await fetch(baseApiUrl + `/editor/pages/${page.id}`, {
+    const res = await apiFetch(baseApiUrl + `/editor/pages/${page.id}`, {
         method: 'PUT',
         headers: {
             'Content-Type': 'application/json',
@@ -113,8 +128,12 @@ export async function updatePage(page: PageRequest) {
     return await res.json();
 }
 
-export async function deletePage(id: number) {
-    const res = await fetch(baseApiUrl + `/editor/pages/${id}`, {
+// refuse keeps pages with child pages, cascade deletes the child pages too and reparent
+// moves them to the parent of the deleted page
+export type DeleteMode = 'refuse' | 'cascade' | 'reparent';
+
+export async function deletePage(id: number, mode: DeleteMode = 'refuse') {
+    const res = await apiFetch(baseApiUrl + `/editor/pages/${id}?mode=${mode}`, {
         method: 'DELETE',
     });
     if (res.status >= 400) {
@@ -124,7 +143,7 @@ export async function deletePage(id: number) {
 }
 
 export async function rebuildSearchIndex() {
-    const res = await fetch(baseApiUrl + `/admin/pages/rebuildsearch`, {
+    const res = await apiFetch(baseApiUrl + `/admin/pages/rebuildsearch`, {
         method: 'POST',
     });
     if (res.status >= 400) {
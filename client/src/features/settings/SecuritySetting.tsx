@@ -1,6 +1,7 @@
 import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
 import React, { useState } from "react";
 import { useTranslation } from "react-i18next";
+import { apiFetch } from "../../common/baseApi";
 
 interface SecuritySetting {
     allow_cors: boolean;
@@ -21,7 +22,7 @@ export default function SecuritySetting() {
     const { data: securitySetting, isLoading, error } = useQuery({
         queryKey: ['security-setting'],
         queryFn: async () => {
-            const response = await fetch('/api/securitysetting');
+            const response = await apiFetch('/api/securitysetting');
             if (!response.ok) {
                 throw new Error(t('Failed to fetch security setting'));
             }
@@ -37,7 +38,7 @@ export default function SecuritySetting() {
 
     const mutation = useMutation({
         mutationFn: async (data: SecuritySetting) => {
-            const response = await fetch('/api/admin/securitysetting', {
+            const response = await apiFetch('/api/admin/securitysetting', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(data),
@@ -53,7 +54,7 @@ export default function SecuritySetting() {
 
     const mutationReset = useMutation({
         mutationFn: async () => {
-            const response = await fetch('/api/admin/securitysetting?defaults=true', {
+            const response = await apiFetch('/api/admin/securitysetting?defaults=true', {
                 method: 'POST',
             });
             if (!response.ok) {
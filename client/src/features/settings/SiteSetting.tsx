@@ -4,6 +4,7 @@ import ImageInput from "../../components/ImageInput";
 import { useTranslation } from "react-i18next";
 import LanguageDropDown from "../../i18n/LanguageDropDown";
 import ThemeDropDown from "../../components/ThemeDropDown";
+import { apiFetch } from "../../common/baseApi";
 
 export default function SiteSetting() {
     const { t } = useTranslation();
@@ -11,7 +12,7 @@ export default function SiteSetting() {
     const { data: siteSetting, isLoading, error } = useQuery({
         queryKey: ['site-setting'],
         queryFn: async () => {
-            const response = await fetch('/api/setting');
+            const response = await apiFetch('/api/setting');
             if (!response.ok) {
                 throw new Error('Failed to fetch site setting');
             }
@@ -27,7 +28,7 @@ export default function SiteSetting() {
 
     const mutation = useMutation({
         mutationFn: async (data: any) => {
-            const response = await fetch('/api/admin/setting', {
+            const response = await apiFetch('/api/admin/setting', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(data),
@@ -1,4 +1,4 @@
-import { baseApiUrl } from "../../common/baseApi";
+import { apiFetch, baseApiUrl } from "../../common/baseApi";
 import { getErrorMessage } from "../../common/errorMessage";
 
 export interface User {
@@ -10,14 +10,14 @@ export interface User {
 }
 
 export async function getUsersApi(): Promise<User[]> {
-  const resp = await fetch(baseApiUrl + "/admin/users", {
+  const resp = await apiFetch(baseApiUrl + "/admin/users", {
     credentials: "include",
   });
   return await resp.json();
 }
 
 export async function getUserApi(id: number): Promise<User> {
-  const resp = await fetch(baseApiUrl + "/admin/users/" + id, {
+  const resp = await apiFetch(baseApiUrl + "/admin/users/" + id, {
     credentials: "include",
   });
   return await resp.json();
@@ -31,7 +31,7 @@ export interface CreateUserRequest {
 }
 
 export async function createUserApi(request: CreateUserRequest): Promise<User> {
-  const resp = await fetch(baseApiUrl + "/admin/users", {
+  const resp = await apiFetch(baseApiUrl + "/admin/users", {
     method: "POST",
     credentials: "include",
     headers: {
@@ -54,7 +54,7 @@ export interface UpdateUserRequest {
 }
 
 export async function updateUserApi(request: UpdateUserRequest): Promise<User> {
-  const resp = await fetch(baseApiUrl + "/admin/users/" + request.id, {
+  const resp = await apiFetch(baseApiUrl + "/admin/users/" + request.id, {
     method: "PUT",
     credentials: "include",
     headers: {
@@ -84,7 +84,7 @@ export function getRolesApi(): Role[] {
 }
 
 export async function getMeApi(): Promise<User> {
-  const resp = await fetch(baseApiUrl + "/user/me", {
+  const resp = await apiFetch(baseApiUrl + "/user/me", {
     credentials: "include",
   });
   return await resp.json();
@@ -14,20 +14,40 @@ type DBManager interface {
 	Init() error
 	Users() users.UserRepository
 	UserDevices() users.UserDeviceRepository
+	Groups() users.GroupRepository
+	ApiTokens() users.ApiTokenRepository
+	UserSessions() users.UserSessionRepository
+	RefreshTokens() users.RefreshTokenRepository
 	Pages() pages.PageRepository
 	Keys() keymgmt.KeyRepository
 	PageRevisions() revisions.RevisionRepository[*pages.Page]
+	PageChanges() pages.PageChangeRepository
+	Redirects() pages.RedirectRepository
 	SearchTerms() pages.SearchTermListRepository
+	SearchDocuments() pages.SearchDocumentRepository
+	SearchIndexes() pages.SearchIndexStore
+	PageLinks() pages.PageLinkListRepository
+	PageAcls() pages.PageAclRepository
 	Settings() setting.SettingRepository
 }
 
 type dbManager struct {
 	users         users.UserRepository
 	userDevices   users.UserDeviceRepository
+	groups        users.GroupRepository
+	apiTokens     users.ApiTokenRepository
+	userSessions  users.UserSessionRepository
+	refreshTokens users.RefreshTokenRepository
 	pages         pages.PageRepository
 	keys          keymgmt.KeyRepository
 	pageRevisions revisions.RevisionRepository[*pages.Page]
+	pageChanges   pages.PageChangeRepository
+	redirects     pages.RedirectRepository
 	searchTerms   pages.SearchTermListRepository
+	searchDocs    pages.SearchDocumentRepository
+	searchIndexes pages.SearchIndexStore
+	pageLinks     pages.PageLinkListRepository
+	pageAcls      pages.PageAclRepository
 	settings      setting.SettingRepository
 }
 
@@ -35,10 +55,18 @@ func NewDBManager(path string) DBManager {
 	return &dbManager{
 		users:         repositories.NewUserDB(path + "/users"),
 		userDevices:   repositories.NewUserDeviceDB(path + "/user_devices"),
+		groups:        repositories.NewGroupDB(path + "/groups"),
+		apiTokens:     repositories.NewApiTokenDB(path + "/api_tokens"),
+		userSessions:  repositories.NewUserSessionDB(path + "/user_sessions"),
+		refreshTokens: repositories.NewRefreshTokenDB(path + "/refresh_tokens"),
 		pages:         repositories.NewPageDB(path + "/pages"),
 		keys:          repositories.NewKeyDB(path + "/keys"),
 		pageRevisions: repositories.NewRevisionRepository[*pages.Page](path + "/revisions"),
-		searchTerms:   repositories.NewSearchTermListRepository(path + "/search_terms"),
+		pageChanges:   repositories.NewPageChangeDB(path + "/page_changes"),
+		redirects:     repositories.NewRedirectDB(path + "/redirects"),
+		searchIndexes: repositories.NewSearchIndexStore(path),
+		pageLinks:     repositories.NewPageLinkListRepository(path + "/page_links"),
+		pageAcls:      repositories.NewPageAclDB(path + "/page_acls"),
 		settings:      &repositories.SettingRepository{Path: filepath.Join(path, "setting.json")},
 	}
 }
@@ -50,6 +78,18 @@ func (m *dbManager) Init() error {
 	if err := m.userDevices.Init(); err != nil {
 		return err
 	}
+	if err := m.groups.Init(); err != nil {
+		return err
+	}
+	if err := m.apiTokens.Init(); err != nil {
+		return err
+	}
+	if err := m.userSessions.Init(); err != nil {
+		return err
+	}
+	if err := m.refreshTokens.Init(); err != nil {
+		return err
+	}
 	if err := m.pages.Init(); err != nil {
 		return err
 	}
@@ -59,7 +99,24 @@ func (m *dbManager) Init() error {
 	if err := m.pageRevisions.Init(); err != nil {
 		return err
 	}
-	if err := m.searchTerms.Init(); err != nil {
+	if err := m.pageChanges.Init(); err != nil {
+		return err
+	}
+	if err := m.redirects.Init(); err != nil {
+		return err
+	}
+	// The active search index changes when the index is rebuilt
+	state, err := m.searchIndexes.GetState()
+	if err != nil {
+		return err
+	}
+	if m.searchTerms, m.searchDocs, err = m.searchIndexes.OpenIndex(state.Active); err != nil {
+		return err
+	}
+	if err := m.pageLinks.Init(); err != nil {
+		return err
+	}
+	if err := m.pageAcls.Init(); err != nil {
 		return err
 	}
 	return nil
@@ -73,6 +130,22 @@ func (m *dbManager) UserDevices() users.UserDeviceRepository {
 	return m.userDevices
 }
 
+func (m *dbManager) Groups() users.GroupRepository {
+	return m.groups
+}
+
+func (m *dbManager) ApiTokens() users.ApiTokenRepository {
+	return m.apiTokens
+}
+
+func (m *dbManager) UserSessions() users.UserSessionRepository {
+	return m.userSessions
+}
+
+func (m *dbManager) RefreshTokens() users.RefreshTokenRepository {
+	return m.refreshTokens
+}
+
 func (m *dbManager) Pages() pages.PageRepository {
 	return m.pages
 }
@@ -85,10 +158,34 @@ func (m *dbManager) PageRevisions() revisions.RevisionRepository[*pages.Page] {
 	return m.pageRevisions
 }
 
+func (m *dbManager) PageChanges() pages.PageChangeRepository {
+	return m.pageChanges
+}
+
+func (m *dbManager) Redirects() pages.RedirectRepository {
+	return m.redirects
+}
+
 func (m *dbManager) SearchTerms() pages.SearchTermListRepository {
 	return m.searchTerms
 }
 
+func (m *dbManager) SearchDocuments() pages.SearchDocumentRepository {
+	return m.searchDocs
+}
+
+func (m *dbManager) SearchIndexes() pages.SearchIndexStore {
+	return m.searchIndexes
+}
+
+func (m *dbManager) PageLinks() pages.PageLinkListRepository {
+	return m.pageLinks
+}
+
+func (m *dbManager) PageAcls() pages.PageAclRepository {
+	return m.pageAcls
+}
+
 func (m *dbManager) Settings() setting.SettingRepository {
 	return m.settings
 }
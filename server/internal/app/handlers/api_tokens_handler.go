@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"wikigo/internal/common/apihelper"
+	"wikigo/internal/common/errors"
+	"wikigo/internal/users"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type ApiTokensHandler struct {
+	ApiTokenService *users.ApiTokenService
+}
+
+type ApiTokenResponse struct {
+	ID         int                 `json:"id"`
+	Name       string              `json:"name"`
+	Scope      users.ApiTokenScope `json:"scope"`
+	Prefix     string              `json:"prefix"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	ExpiresAt  time.Time           `json:"expiresAt"`
+	LastUsedAt *time.Time          `json:"lastUsedAt"`
+	// Token is only returned when the token is created
+	Token string `json:"token,omitempty"`
+}
+
+func ToApiTokenResponse(token *users.ApiToken) *ApiTokenResponse {
+	return &ApiTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scope:      token.Scope,
+		Prefix:     token.Prefix,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
+type CreateApiTokenRequest struct {
+	Name          string `json:"name" validate:"required,max=100"`
+	Scope         string `json:"scope" validate:"required,oneof=read edit"`
+	ExpiresInDays int    `json:"expiresInDays" validate:"required,min=1,max=365"`
+}
+
+func (h *ApiTokensHandler) GetTokens(e echo.Context) error {
+	tokens, err := h.ApiTokenService.ListTokens(apihelper.GetUserId(e))
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	resp := make([]*ApiTokenResponse, len(tokens))
+	for i, token := range tokens {
+		resp[i] = ToApiTokenResponse(token)
+	}
+	return e.JSON(200, resp)
+}
+
+// CreateToken creates a personal access token.
+func (h *ApiTokensHandler) CreateToken(e echo.Context) error {
+	req := new(CreateApiTokenRequest)
+	if err := e.Bind(req); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return errors.BadRequest("invalid request: " + err.Error())
+	}
+	expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+	token, value, err := h.ApiTokenService.CreateToken(apihelper.GetUserId(e), req.Name, users.ApiTokenScope(req.Scope), expiresAt)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	resp := ToApiTokenResponse(token)
+	resp.Token = value
+	return e.JSON(201, resp)
+}
+
+func (h *ApiTokensHandler) RevokeToken(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid token id")
+	}
+	if err := h.ApiTokenService.RevokeToken(apihelper.GetUserId(e), id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return apihelper.OkMessage(e, "token revoked")
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/base64"
-	"net/http"
 	"time"
 
 	"wikigo/internal/common/apihelper"
@@ -18,6 +17,7 @@ import (
 type AuthHandler struct {
 	UserService *users.UserService
 	KeyStore    *keymgmt.KeyMgmtService
+	TokenIssuer *AuthTokenIssuer
 	RateLimiter *apihelper.RateLimiter
 }
 
@@ -89,32 +89,10 @@ func (h *AuthHandler) Login(e echo.Context) error {
 	if err != nil {
 		return errors.Unauthorized("invalid username or password")
 	}
-	tokenExpiry := time.Now().Add(time.Hour * 24)
-	signedToken, err := h.KeyStore.SignJWT(jwt.MapClaims{
-		"uid":   user.UserName,
-		"scope": user.Role,
-		"iat":   time.Now().Unix(),
-		"exp":   tokenExpiry.Unix(),
-	}, "auth")
-
+	signedToken, err := h.TokenIssuer.SignIn(e, user)
 	if err != nil {
 		return err
 	}
-	e.SetCookie(&http.Cookie{
-		Name:     "user",
-		Value:    user.UserName,
-		Expires:  tokenExpiry,
-		SameSite: http.SameSiteDefaultMode,
-		Path:     "/",
-	})
-	e.SetCookie(&http.Cookie{
-		Name:     "token",
-		Value:    signedToken,
-		Expires:  tokenExpiry,
-		SameSite: http.SameSiteDefaultMode,
-		HttpOnly: true,
-		Path:     "/",
-	})
 	return e.JSON(200, &LoginResponse{Token: signedToken})
 }
 
@@ -179,8 +157,19 @@ func (h *AuthHandler) GetRole(e echo.Context) error {
 	return e.JSON(200, &RoleResponse{Role: role})
 }
 
+// Refresh renews the access token of a login with its refresh token cookie.
+func (h *AuthHandler) Refresh(e echo.Context) error {
+	signedToken, err := h.TokenIssuer.Refresh(e)
+	if err != nil {
+		return err
+	}
+	return e.JSON(200, &LoginResponse{Token: signedToken})
+}
+
 func (h *AuthHandler) Logout(e echo.Context) error {
-	apihelper.RemoveAuthCookie(e)
+	if err := h.TokenIssuer.SignOut(e); err != nil {
+		return err
+	}
 	return apihelper.OkMessage(e, "logged out successfully")
 }
 
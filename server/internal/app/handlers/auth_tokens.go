@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"wikigo/internal/common/apihelper"
+	"wikigo/internal/common/errors"
+	"wikigo/internal/keymgmt"
+	"wikigo/internal/users"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// accessTokenLifetime is kept short, as a signed token stays valid until it expires;
+	// the refresh token is used to get a new one
+	accessTokenLifetime = 15 * time.Minute
+	// sessionLifetime is the longest a login lasts, however often its token is refreshed
+	sessionLifetime = 30 * 24 * time.Hour
+)
+
+// AuthTokenIssuer signs the auth tokens of a login and sets the auth cookies. Every login
+// starts a session, which the auth middleware checks on each request. The access token is
+// short-lived and renewed with a single-use refresh token bound to the session.
+type AuthTokenIssuer struct {
+	KeyStore       *keymgmt.KeyMgmtService
+	UserService    *users.UserService
+	GroupService   *users.GroupService
+	SessionService *users.UserSessionService
+}
+
+// SignIn issues the tokens of a user who just logged in and returns the access token.
+func (i *AuthTokenIssuer) SignIn(e echo.Context, user *users.User) (string, error) {
+	session, err := i.SessionService.CreateSession(user.UserName, time.Now().Add(sessionLifetime), e.RealIP(), e.Request().UserAgent())
+	if err != nil {
+		return "", err
+	}
+	refreshToken, refreshExpiry, err := i.SessionService.IssueRefreshToken(session)
+	if err != nil {
+		return "", err
+	}
+	return i.issueAccessToken(e, user, session.SessionID, refreshToken, refreshExpiry)
+}
+
+// Refresh exchanges the refresh token cookie for a new access token and refresh token.
+// The role and groups of the user are read again, so changes apply from the next refresh.
+func (i *AuthTokenIssuer) Refresh(e echo.Context) (string, error) {
+	cookie, err := e.Cookie("refresh_token")
+	if err != nil || cookie.Value == "" {
+		return "", errors.Unauthorized("not logged in")
+	}
+	session, refreshToken, refreshExpiry, err := i.SessionService.RotateRefreshToken(cookie.Value)
+	if err != nil {
+		if _, ok := err.(*users.UnauthorizedError); ok && err != users.ErrRefreshTokenReplaced {
+			apihelper.RemoveAuthCookie(e)
+		}
+		return "", err
+	}
+	user, err := i.UserService.DB.GetUserByUserName(session.UserName)
+	if err != nil {
+		return "", err
+	}
+	if user == nil || user.IsLockedOut {
+		apihelper.RemoveAuthCookie(e)
+		if err := i.SessionService.RevokeSessionByID(session.SessionID); err != nil {
+			return "", err
+		}
+		return "", errors.Unauthorized("not logged in")
+	}
+	return i.issueAccessToken(e, user, session.SessionID, refreshToken, refreshExpiry)
+}
+
+// SignOut ends the session of the request and removes the auth cookies. The session is
+// found through the refresh token when the access token has expired.
+func (i *AuthTokenIssuer) SignOut(e echo.Context) error {
+	apihelper.RemoveAuthCookie(e)
+	if sessionID := apihelper.GetSessionID(e); sessionID != "" {
+		return i.SessionService.RevokeSessionByID(sessionID)
+	}
+	if cookie, err := e.Cookie("refresh_token"); err == nil && cookie.Value != "" {
+		return i.SessionService.RevokeSessionByRefreshToken(cookie.Value)
+	}
+	return nil
+}
+
+// issueAccessToken signs an access token for the session and sets the auth cookies.
+// The user cookie lives as long as the refresh token, so the client stays logged in
+// while the access token is renewed.
+func (i *AuthTokenIssuer) issueAccessToken(e echo.Context, user *users.User, sessionID, refreshToken string, refreshExpiry time.Time) (string, error) {
+	tokenExpiry := time.Now().Add(accessTokenLifetime)
+	claims, err := i.newAuthClaims(user, sessionID, tokenExpiry)
+	if err != nil {
+		return "", err
+	}
+	signedToken, err := i.KeyStore.SignJWT(claims, "auth")
+	if err != nil {
+		return "", err
+	}
+	e.SetCookie(&http.Cookie{
+		Name:     "user",
+		Value:    user.UserName,
+		Expires:  refreshExpiry,
+		SameSite: http.SameSiteDefaultMode,
+		Path:     "/",
+	})
+	e.SetCookie(&http.Cookie{
+		Name:     "token",
+		Value:    signedToken,
+		Expires:  tokenExpiry,
+		SameSite: http.SameSiteDefaultMode,
+		HttpOnly: true,
+		Path:     "/",
+	})
+	e.SetCookie(&http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Expires:  refreshExpiry,
+		SameSite: http.SameSiteStrictMode,
+		HttpOnly: true,
+		Path:     "/",
+	})
+	return signedToken, nil
+}
+
+// newAuthClaims returns the claims of an access token. The groups of the user are
+// recorded in the token, so membership changes apply from the next refresh.
+func (i *AuthTokenIssuer) newAuthClaims(user *users.User, sessionID string, expiry time.Time) (jwt.MapClaims, error) {
+	groups := []string{}
+	if i.GroupService != nil {
+		var err error
+		if groups, err = i.GroupService.GetUserGroups(user.UserName); err != nil {
+			return nil, err
+		}
+	}
+	return jwt.MapClaims{
+		"uid":    user.UserName,
+		"scope":  user.Role,
+		"groups": groups,
+		"jti":    sessionID,
+		"iat":    time.Now().Unix(),
+		"exp":    expiry.Unix(),
+	}, nil
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"strconv"
 	"sync"
 	"time"
@@ -18,7 +17,6 @@ import (
 	"wikigo/internal/users"
 
 	"github.com/go-webauthn/webauthn/webauthn"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
@@ -26,6 +24,7 @@ type Fido2Handler struct {
 	UserService  *users.UserService
 	WebAuthn     *webauthn.WebAuthn
 	KeyStore     *keymgmt.KeyMgmtService
+	TokenIssuer  *AuthTokenIssuer
 	SessionStore *SessionStore
 	RateLimiter  *apihelper.RateLimiter
 }
@@ -392,34 +391,11 @@ func (h *Fido2Handler) FinishLogin(e echo.Context) error {
 		}
 	}
 
-	tokenExpiry := time.Now().Add(time.Hour * 24)
-	signedToken, err := h.KeyStore.SignJWT(jwt.MapClaims{
-		"uid":   webAuthnUser.UserName,
-		"scope": webAuthnUser.Role,
-		"iat":   time.Now().Unix(),
-		"exp":   tokenExpiry.Unix(),
-	}, "auth")
-
+	signedToken, err := h.TokenIssuer.SignIn(e, webAuthnUser.User)
 	if err != nil {
 		return err
 	}
 
-	e.SetCookie(&http.Cookie{
-		Name:     "user",
-		Value:    webAuthnUser.UserName,
-		Expires:  tokenExpiry,
-		SameSite: http.SameSiteDefaultMode,
-		Path:     "/",
-	})
-	e.SetCookie(&http.Cookie{
-		Name:     "token",
-		Value:    signedToken,
-		Expires:  tokenExpiry,
-		SameSite: http.SameSiteDefaultMode,
-		HttpOnly: true,
-		Path:     "/",
-	})
-
 	// Return similar to regular login
 	type LoginResponse struct {
 		Token string `json:"token"`
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"strconv"
+
+	"wikigo/internal/common/apihelper"
+	"wikigo/internal/common/errors"
+	"wikigo/internal/users"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type GroupsHandler struct {
+	GroupService *users.GroupService
+}
+
+type GroupRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Description string   `json:"description" validate:"max=200"`
+	Members     []string `json:"members"`
+}
+
+type GroupMemberRequest struct {
+	UserName string `json:"username" validate:"required,max=50"`
+}
+
+func (h *GroupsHandler) GetGroups(e echo.Context) error {
+	groups, err := h.GroupService.ListGroups()
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, groups)
+}
+
+func (h *GroupsHandler) GetGroup(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid group id")
+	}
+	group, err := h.GroupService.GetGroup(id)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, group)
+}
+
+func (h *GroupsHandler) CreateGroup(e echo.Context) error {
+	req := new(GroupRequest)
+	if err := e.Bind(req); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return errors.BadRequest("invalid request: " + err.Error())
+	}
+	group := &users.Group{Name: req.Name, Description: req.Description, Members: req.Members}
+	if err := h.GroupService.CreateGroup(group); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(201, group)
+}
+
+func (h *GroupsHandler) UpdateGroup(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid group id")
+	}
+	req := new(GroupRequest)
+	if err := e.Bind(req); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return errors.BadRequest("invalid request: " + err.Error())
+	}
+	group := &users.Group{ID: id, Name: req.Name, Description: req.Description, Members: req.Members}
+	if err := h.GroupService.UpdateGroup(group); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, group)
+}
+
+func (h *GroupsHandler) DeleteGroup(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid group id")
+	}
+	if err := h.GroupService.DeleteGroup(id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return apihelper.OkMessage(e, "group deleted")
+}
+
+func (h *GroupsHandler) AddMember(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid group id")
+	}
+	req := new(GroupMemberRequest)
+	if err := e.Bind(req); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return errors.BadRequest("invalid request: " + err.Error())
+	}
+	group, err := h.GroupService.AddMember(id, req.UserName)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, group)
+}
+
+func (h *GroupsHandler) RemoveMember(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid group id")
+	}
+	group, err := h.GroupService.RemoveMember(id, e.Param("username"))
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, group)
+}
+
+// GetUserGroups lists the groups of a user.
+func (h *GroupsHandler) GetUserGroups(e echo.Context) error {
+	groups, err := h.GroupService.GetUserGroups(e.Param("username"))
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, groups)
+}
@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"log"
+	"path"
 	"strconv"
+	"strings"
 
 	"wikigo/internal/common/apihelper"
 	"wikigo/internal/common/errors"
 	"wikigo/internal/pages"
 	"wikigo/internal/revisions"
+	"wikigo/internal/roles"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
@@ -18,6 +21,10 @@ type PageHandler struct {
 	PageService         *pages.PageService
 	SearchService       *pages.SearchService
 	PageRevisionService *revisions.RevisionService[*pages.Page]
+	PageDiffService     *pages.PageDiffService
+	RedirectService     *pages.RedirectService
+	LinkService         *pages.LinkService
+	AccessService       *pages.PageAccessService
 	HtmlPolicy          *bluemonday.Policy
 	ReactPage           *pages.ReactPageMeta
 }
@@ -32,17 +39,23 @@ func (h *PageHandler) GetPageByID(e echo.Context) error {
 	if err != nil {
 		return errors.NotFound("page not found")
 	}
+	if err := h.checkCanView(e, page); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
 	return e.JSON(200, pages.NewReactPage(page, h.ReactPage))
 }
 
 func (h *PageHandler) GetPageByUrl(e echo.Context) error {
-	url := e.Param("url")
-	page, err := h.PageService.GetPageByUrl("/" + url)
+	url := "/" + e.Param("*")
+	page, err := h.PageService.GetPageByUrl(url)
 	if err != nil {
+		if target, _ := h.RedirectService.ResolveUrl(url); target != "" && !pages.IsExternalUrl(target) {
+			return e.Redirect(301, strings.TrimSuffix(e.Path(), "*")+strings.TrimPrefix(target, "/"))
+		}
 		return errors.NotFound("page not found")
 	}
-	if page.IsProtected && apihelper.GetUserId(e) == "" {
-		return errors.Forbidden("page is protected")
+	if err := h.checkCanView(e, page); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
 	}
 	return e.JSON(200, page)
 }
@@ -53,7 +66,11 @@ func (h *PageHandler) GetPagesByAuthor(e echo.Context) error {
 	if err != nil {
 		return errors.NotFound("pages not found")
 	}
-	return e.JSON(200, pages)
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, access.FilterPages(pages))
 }
 
 func (h *PageHandler) GetPagesByParentID(e echo.Context) error {
@@ -71,7 +88,11 @@ func (h *PageHandler) GetPagesByParentID(e echo.Context) error {
 	if err != nil {
 		return errors.NotFound("pages not found")
 	}
-	return e.JSON(200, pages)
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, access.FilterPages(pages))
 }
 
 func (h *PageHandler) GetAllPages(e echo.Context) error {
@@ -80,33 +101,110 @@ func (h *PageHandler) GetAllPages(e echo.Context) error {
 	if err != nil {
 		return err
 	}
-	return e.JSON(200, pages)
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, access.FilterPages(pages))
 }
 
 func (h *PageHandler) SearchPages(e echo.Context) error {
 	query := e.QueryParam("q")
-	if query == "" {
+	options, err := parseSearchOptions(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if options.Access, err = h.getPageAccess(e); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if query == "" && !options.HasFilters() {
 		return errors.NewValidationError("query parameter 'q' is required", "q")
 	}
-	pages, err := h.SearchService.Search(query, 10)
+	results, err := h.SearchService.Search(query, options)
 	if err != nil {
 		return apihelper.ReturnErrorResponse(e, err)
 	}
-	return e.JSON(200, pages)
+	// Clients that do not page the results get the list of results as before
+	if e.QueryParam("offset") == "" && e.QueryParam("limit") == "" {
+		return e.JSON(200, results.Results)
+	}
+	return e.JSON(200, results)
+}
+
+func parseSearchOptions(e echo.Context) (*pages.SearchOptions, error) {
+	offset, limit, err := getOffsetAndLimit(e, 10)
+	if err != nil {
+		return nil, err
+	}
+	options := &pages.SearchOptions{
+		IncludeProtected: apihelper.GetUserId(e) != "",
+		Offset:           offset,
+		Limit:            limit,
+		Author:           strings.TrimSpace(e.QueryParam("author")),
+	}
+	for _, tag := range strings.Split(e.QueryParam("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			options.Tags = append(options.Tags, tag)
+		}
+	}
+	if s := e.QueryParam("parentId"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, errors.NewValidationError("invalid parent id", "parentId")
+		}
+		options.ParentID = &id
+	}
+	if options.ModifiedSince, err = parseDateParam(e.QueryParam("since"), "since"); err != nil {
+		return nil, err
+	}
+	if options.ModifiedUntil, err = parseUntilParam(e.QueryParam("until"), "until"); err != nil {
+		return nil, err
+	}
+	switch sort := pages.SearchSort(e.QueryParam("sort")); sort {
+	case pages.SearchSortRelevance, pages.SearchSortTitle, pages.SearchSortModified:
+		options.Sort = sort
+	case "relevance":
+		options.Sort = pages.SearchSortRelevance
+	default:
+		return nil, errors.NewValidationError("invalid sort, use relevance, title or modified", "sort")
+	}
+	return options, nil
+}
+
+func (h *PageHandler) SuggestPages(e echo.Context) error {
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	suggestions, err := h.SearchService.Suggest(e.QueryParam("q"), access)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, suggestions)
 }
 
 func (h *PageHandler) Page(e echo.Context) error {
 	if h.ReactPage == nil {
 		return e.Redirect(302, "/")
 	}
-	idStr := e.Param("id")
-	page, err := h.PageService.GetPageByUrl("/" + idStr)
+	url := "/" + e.Param("*")
+	page, err := h.PageService.GetPageByUrl(url)
 	if err != nil || page == nil {
+		if target, err := h.RedirectService.ResolveUrl(url); err != nil {
+			log.Println("Redirect failed:", err)
+		} else if pages.IsExternalUrl(target) {
+			return e.Redirect(301, target)
+		} else if target != "" {
+			return e.Redirect(301, "/p"+target)
+		}
 		log.Println("Page not found:", err)
 		return e.Render(404, "404", nil)
 	}
-	if page.IsProtected && apihelper.GetUserId(e) == "" {
-		return e.Redirect(302, "/login")
+	if err := h.checkCanView(e, page); err != nil {
+		if apihelper.GetUserId(e) == "" {
+			return e.Redirect(302, "/login")
+		}
+		return e.Render(404, "404", nil)
 	}
 	return e.Render(200, "page", pages.NewReactPage(page, h.ReactPage))
 }
@@ -117,6 +215,9 @@ func (h *PageHandler) GetLatestRevision(e echo.Context) error {
 	if err != nil {
 		return errors.NewValidationError("invalid page id", "id")
 	}
+	if err := h.checkCanViewID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
 	revision, err := h.PageRevisionService.GetLatestRevision(id)
 	if err != nil {
 		return errors.NotFound("revision not found")
@@ -124,6 +225,134 @@ func (h *PageHandler) GetLatestRevision(e echo.Context) error {
 	return e.JSON(200, revision)
 }
 
+type RevisionListResponse struct {
+	Revisions []*revisions.Revision[*pages.Page] `json:"revisions"`
+	Total     int                                `json:"total"`
+}
+
+func (h *PageHandler) ListRevisions(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	if err := h.checkCanViewID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	offset, limit, err := getOffsetAndLimit(e, 20)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	list, total, err := h.PageRevisionService.ListRevisions(id, offset, limit)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, &RevisionListResponse{Revisions: list, Total: total})
+}
+
+func (h *PageHandler) ListRevisionsByAuthor(e echo.Context) error {
+	author := e.Param("author")
+	if author == "" {
+		return errors.NewValidationError("author is required", "author")
+	}
+	offset, limit, err := getOffsetAndLimit(e, 20)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	visible := func(revision *revisions.Revision[*pages.Page]) bool {
+		return revision.Record != nil && access.CanView(revision.RecordID, revision.Record.ParentID, revision.Record.IsProtected)
+	}
+	list, total, err := h.PageRevisionService.ListRevisionsByAuthor(author, offset, limit, visible)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, &RevisionListResponse{Revisions: list, Total: total})
+}
+
+func (h *PageHandler) GetRevision(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	revisionID, err := strconv.Atoi(e.Param("revisionId"))
+	if err != nil {
+		return errors.NewValidationError("invalid revision id", "revisionId")
+	}
+	if err := h.checkCanViewID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	revision, err := h.PageRevisionService.GetRevision(revisionID)
+	if err != nil || revision == nil || revision.RecordID != id {
+		return errors.NotFound("revision not found")
+	}
+	return e.JSON(200, revision)
+}
+
+func (h *PageHandler) RestoreRevision(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	revisionID, err := strconv.Atoi(e.Param("revisionId"))
+	if err != nil {
+		return errors.NewValidationError("invalid revision id", "revisionId")
+	}
+	if err := h.checkCanEditID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	page, err := h.PageService.RestoreRevision(id, revisionID, apihelper.GetUserId(e))
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, page)
+}
+
+// GetPageDiff compares two versions of a page. The from and to query parameters take a
+// revision ID or "current"; by default the latest revision is compared with the current page.
+func (h *PageHandler) GetPageDiff(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	from, err := parseRevisionParam(e.QueryParam("from"), "from")
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	to, err := parseRevisionParam(e.QueryParam("to"), "to")
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if err := h.checkCanViewID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if e.QueryParam("from") == "" {
+		latest, err := h.PageRevisionService.GetLatestRevision(id)
+		if err != nil || latest == nil {
+			return apihelper.ReturnErrorResponse(e, errors.NotFound("revision not found"))
+		}
+		from = latest.ID
+	}
+	diff, err := h.PageDiffService.Diff(id, from, to)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, diff)
+}
+
+func parseRevisionParam(value string, field string) (int, error) {
+	if value == "" || value == "current" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, errors.NewValidationError("invalid revision id", field)
+	}
+	return id, nil
+}
+
 type CreatePageRequest struct {
 	Url              string   `json:"url" validate:"required,max=100"`
 	Title            string   `json:"title" validate:"required,max=100"`
@@ -157,6 +386,13 @@ func (h *PageHandler) CreatePage(e echo.Context) error {
 	page.IsProtected = req.IsProtected
 	page.IsCategoryPage = req.IsCategoryPage
 	page.SortChildrenDesc = req.SortChildrenDesc
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if !access.CanCreate(page.ParentID) {
+		return apihelper.ReturnErrorResponse(e, errors.Forbidden("you cannot add pages here"))
+	}
 	if err := h.PageService.CreatePage(page, apihelper.GetUserId(e)); err != nil {
 		return apihelper.ReturnErrorResponse(e, err)
 	}
@@ -175,6 +411,7 @@ type UpdatePageRequest struct {
 	IsProtected      bool     `json:"isProtected"`
 	IsCategoryPage   bool     `json:"isCategoryPage"`
 	SortChildrenDesc bool     `json:"sortChildrenDesc"`
+	Comment          string   `json:"comment" validate:"max=200"`
 }
 
 func (h *PageHandler) UpdatePage(e echo.Context) error {
@@ -197,7 +434,13 @@ func (h *PageHandler) UpdatePage(e echo.Context) error {
 	page.IsProtected = req.IsProtected
 	page.IsCategoryPage = req.IsCategoryPage
 	page.SortChildrenDesc = req.SortChildrenDesc
-	if err := h.PageService.UpdatePage(page, apihelper.GetUserId(e)); err != nil {
+	if err := h.checkCanEditID(e, page.ID); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if err := h.checkCanMoveTo(e, page.ID, page.ParentID); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if err := h.PageService.UpdatePage(page, apihelper.GetUserId(e), req.Comment); err != nil {
 		return apihelper.ReturnErrorResponse(e, err)
 	}
 	return e.JSON(200, req)
@@ -209,15 +452,346 @@ func (h *PageHandler) DeletePage(e echo.Context) error {
 	if err != nil {
 		return errors.NewValidationError("invalid page id", "id")
 	}
-	if err := h.PageService.DeletePage(id); err != nil {
+	mode := pages.DeleteMode(e.QueryParam("mode"))
+	page, err := h.PageService.GetPageByID(id)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	canDelete := access.CanEdit(page.ID, page.ParentID)
+	if mode == pages.DeleteModeCascade {
+		canDelete = access.CanEditSubtree(page.ID, page.ParentID)
+	}
+	if !canDelete {
+		return apihelper.ReturnErrorResponse(e, errors.Forbidden("you cannot delete this page"))
+	}
+	if err := h.PageService.DeletePage(id, apihelper.GetUserId(e), mode); err != nil {
 		return apihelper.ReturnErrorResponse(e, err)
 	}
 	return apihelper.OkMessage(e, "page deleted")
 }
 
+type MovePageRequest struct {
+	Url      string `json:"url" validate:"required,max=100"`
+	ParentID *int   `json:"parentId"`
+}
+
+// MovePage changes the url of a page and its subtree. Links to the moved pages are
+// rewritten and the old urls redirect to the new ones.
+func (h *PageHandler) MovePage(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	req := new(MovePageRequest)
+	if err := e.Bind(req); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return errors.BadRequest("invalid request: " + err.Error())
+	}
+	if err := h.checkCanMove(e, id, req.Url, req.ParentID); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	moves, err := h.PageService.MovePage(id, req.Url, req.ParentID, apihelper.GetUserId(e))
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, moves)
+}
+
+func (h *PageHandler) GetDeletedPages(e echo.Context) error {
+	pages, err := h.PageService.GetDeletedPages()
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, pages)
+}
+
+func (h *PageHandler) RestoreDeletedPage(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	if err := h.PageService.RestoreDeletedPage(id, apihelper.GetUserId(e)); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return apihelper.OkMessage(e, "page restored")
+}
+
+func (h *PageHandler) PurgePage(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	if err := h.PageService.PurgePage(id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return apihelper.OkMessage(e, "page purged")
+}
+
+func (h *PageHandler) CheckSearchIndex(e echo.Context) error {
+	report, err := h.SearchService.CheckSearchIndex(false)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, report)
+}
+
+func (h *PageHandler) RepairSearchIndex(e echo.Context) error {
+	report, err := h.SearchService.CheckSearchIndex(true)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, report)
+}
+
+func (h *PageHandler) GetOrphanPages(e echo.Context) error {
+	pages, err := h.PageService.GetOrphanPages()
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, pages)
+}
+
+func (h *PageHandler) RepairOrphanPages(e echo.Context) error {
+	pages, err := h.PageService.RepairOrphanPages(apihelper.GetUserId(e))
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, pages)
+}
+
+func (h *PageHandler) GetBacklinks(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if err := h.checkCanViewID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	pages, err := h.LinkService.GetBacklinks(id, access)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, pages)
+}
+
+func (h *PageHandler) GetLinks(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if err := h.checkCanViewID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	links, err := h.LinkService.GetLinks(id, access)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, links)
+}
+
+func (h *PageHandler) GetLinkReport(e echo.Context) error {
+	report, err := h.LinkService.GetLinkReport()
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, report)
+}
+
+func (h *PageHandler) RebuildLinkIndex(e echo.Context) error {
+	if err := h.LinkService.RebuildLinkIndex(); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return apihelper.OkMessage(e, "link index rebuilt")
+}
+
+// RebuildSearchIndex starts rebuilding the search index in the background. The progress
+// is reported by GetSearchIndexJob.
 func (h *PageHandler) RebuildSearchIndex(e echo.Context) error {
-	if err := h.SearchService.RebuildSearchIndex(); err != nil {
+	job, err := h.SearchService.StartRebuildSearchIndex()
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(202, job)
+}
+
+func (h *PageHandler) GetSearchIndexJob(e echo.Context) error {
+	return e.JSON(200, h.SearchService.GetSearchIndexJob())
+}
+
+type PageAclRequest struct {
+	Entries []*pages.PageAclEntry `json:"entries" validate:"dive"`
+}
+
+func (h *PageHandler) GetPageAcl(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	if err := h.checkCanManageID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	acl, err := h.AccessService.GetPageAcl(id)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, acl)
+}
+
+// UpdatePageAcl replaces the ACL of a page, which then applies to its subtree until a
+// page below has an ACL of its own.
+func (h *PageHandler) UpdatePageAcl(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	req := new(PageAclRequest)
+	if err := e.Bind(req); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return errors.BadRequest("invalid request: " + err.Error())
+	}
+	if err := h.checkCanManageID(e, id); err != nil {
 		return apihelper.ReturnErrorResponse(e, err)
 	}
-	return apihelper.OkMessage(e, "search index rebuilt")
+	if err := h.AccessService.SetPageAcl(id, req.Entries, getPageViewer(e)); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	acl, err := h.AccessService.GetPageAcl(id)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, acl)
+}
+
+func (h *PageHandler) DeletePageAcl(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.NewValidationError("invalid page id", "id")
+	}
+	if err := h.checkCanManageID(e, id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	if err := h.AccessService.DeletePageAcl(id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return apihelper.OkMessage(e, "page ACL deleted")
+}
+
+func getPageViewer(e echo.Context) *pages.PageViewer {
+	userId, role := apihelper.GetUserIdAndRole(e)
+	return &pages.PageViewer{UserID: userId, Role: roles.Role(role), Groups: apihelper.GetUserGroups(e)}
+}
+
+func (h *PageHandler) getPageAccess(e echo.Context) (*pages.PageAccess, error) {
+	return h.AccessService.NewPageAccess(getPageViewer(e))
+}
+
+func (h *PageHandler) checkCanView(e echo.Context, page *pages.Page) error {
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return err
+	}
+	if !access.CanView(page.ID, page.ParentID, page.IsProtected) {
+		if page.IsProtected && apihelper.GetUserId(e) == "" {
+			return errors.Forbidden("page is protected")
+		}
+		return errors.Forbidden("you do not have access to this page")
+	}
+	return nil
+}
+
+func (h *PageHandler) checkCanViewID(e echo.Context, id int) error {
+	page, err := h.PageService.GetPageByID(id)
+	if err != nil {
+		return err
+	}
+	return h.checkCanView(e, page)
+}
+
+func (h *PageHandler) checkCanEditID(e echo.Context, id int) error {
+	page, err := h.PageService.GetPageByID(id)
+	if err != nil {
+		return err
+	}
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return err
+	}
+	if !access.CanEdit(page.ID, page.ParentID) {
+		return errors.Forbidden("you cannot edit this page")
+	}
+	return nil
+}
+
+func (h *PageHandler) checkCanManageID(e echo.Context, id int) error {
+	page, err := h.PageService.GetPageByID(id)
+	if err != nil {
+		return err
+	}
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return err
+	}
+	if !access.CanManage(page.ID, page.ParentID) {
+		return errors.Forbidden("you cannot manage the access to this page")
+	}
+	return nil
+}
+
+// checkCanMoveTo checks that the viewer may add pages below the new parent of a page.
+func (h *PageHandler) checkCanMoveTo(e echo.Context, id int, parentID *int) error {
+	page, err := h.PageService.GetPageByID(id)
+	if err != nil {
+		return err
+	}
+	if parentID == nil && page.ParentID == nil || parentID != nil && page.ParentID != nil && *parentID == *page.ParentID {
+		return nil
+	}
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return err
+	}
+	if !access.CanCreate(parentID) {
+		return errors.Forbidden("you cannot add pages here")
+	}
+	return nil
+}
+
+// checkCanMove checks that the viewer may change the subtree and add it below the new
+// parent. Without a parent the page moves below the page at the parent url, if any.
+func (h *PageHandler) checkCanMove(e echo.Context, id int, url string, parentID *int) error {
+	page, err := h.PageService.GetPageByID(id)
+	if err != nil {
+		return err
+	}
+	access, err := h.getPageAccess(e)
+	if err != nil {
+		return err
+	}
+	if !access.CanEditSubtree(page.ID, page.ParentID) {
+		return errors.Forbidden("you cannot move this page")
+	}
+	if parentID == nil {
+		if parent, err := h.PageService.GetPageByUrl(path.Dir(url)); err == nil && parent.ID != page.ID && parent.Url != "/" {
+			parentID = &parent.ID
+		} else {
+			parentID = page.ParentID
+		}
+	}
+	return h.checkCanMoveTo(e, id, parentID)
 }
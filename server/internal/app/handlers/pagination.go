@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"strconv"
+
+	"wikigo/internal/common/errors"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxPageLimit = 100
+
+// getOffsetAndLimit reads the offset and limit query parameters used by list endpoints.
+func getOffsetAndLimit(e echo.Context, defaultLimit int) (int, int, error) {
+	offset, limit := 0, defaultLimit
+	if s := e.QueryParam("offset"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			return 0, 0, errors.NewValidationError("invalid offset", "offset")
+		}
+		offset = v
+	}
+	if s := e.QueryParam("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 {
+			return 0, 0, errors.NewValidationError("invalid limit", "limit")
+		}
+		limit = v
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return offset, limit, nil
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"wikigo/internal/common/apihelper"
+	"wikigo/internal/common/errors"
+	"wikigo/internal/common/feeds"
+	"wikigo/internal/pages"
+	"wikigo/internal/setting"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RecentChangesHandler struct {
+	RecentChangeService *pages.RecentChangeService
+	SettingService      *setting.SettingService
+	AccessService       *pages.PageAccessService
+}
+
+func (h *RecentChangesHandler) GetRecentChanges(e echo.Context) error {
+	changes, err := h.getRecentChanges(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, changes)
+}
+
+func (h *RecentChangesHandler) GetAtomFeed(e echo.Context) error {
+	feed, err := h.getFeed(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	data, err := feed.ToAtom()
+	if err != nil {
+		return err
+	}
+	return e.Blob(200, "application/atom+xml; charset=utf-8", data)
+}
+
+func (h *RecentChangesHandler) GetRssFeed(e echo.Context) error {
+	feed, err := h.getFeed(e)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	data, err := feed.ToRss()
+	if err != nil {
+		return err
+	}
+	return e.Blob(200, "application/rss+xml; charset=utf-8", data)
+}
+
+func (h *RecentChangesHandler) getRecentChanges(e echo.Context) ([]*pages.PageChange, error) {
+	access, err := h.AccessService.NewPageAccess(getPageViewer(e))
+	if err != nil {
+		return nil, err
+	}
+	filter := &pages.RecentChangeFilter{
+		Author:           e.QueryParam("user"),
+		IncludeProtected: apihelper.GetUserId(e) != "",
+		Access:           access,
+		Limit:            50,
+	}
+	if s := e.QueryParam("parentId"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, errors.NewValidationError("invalid parent id", "parentId")
+		}
+		filter.ParentID = &id
+	}
+	if filter.Since, err = parseDateParam(e.QueryParam("since"), "since"); err != nil {
+		return nil, err
+	}
+	if filter.Until, err = parseUntilParam(e.QueryParam("until"), "until"); err != nil {
+		return nil, err
+	}
+	if s := e.QueryParam("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit <= 0 {
+			return nil, errors.NewValidationError("invalid limit", "limit")
+		}
+		filter.Limit = min(limit, maxPageLimit)
+	}
+	return h.RecentChangeService.GetRecentChanges(filter)
+}
+
+func (h *RecentChangesHandler) getFeed(e echo.Context) (*feeds.Feed, error) {
+	changes, err := h.getRecentChanges(e)
+	if err != nil {
+		return nil, err
+	}
+	siteName, siteUrl := "WikiGO", ""
+	if s, err := h.SettingService.GetSetting(); err == nil && s != nil {
+		if s.SiteName != "" {
+			siteName = s.SiteName
+		}
+		siteUrl = strings.TrimSuffix(s.SiteURL, "/")
+	}
+	if siteUrl == "" {
+		siteUrl = e.Scheme() + "://" + e.Request().Host
+	}
+
+	feed := &feeds.Feed{
+		Title:       siteName + " - Recent changes",
+		Link:        siteUrl + "/",
+		Description: "Recent page changes on " + siteName,
+		Updated:     time.Now(),
+	}
+	if len(changes) > 0 {
+		feed.Updated = changes[0].ChangeDate
+	}
+	for _, change := range changes {
+		description := fmt.Sprintf("Page %sd by %s", change.Action, change.Author)
+		if change.Comment != "" {
+			description += ": " + change.Comment
+		}
+		feed.Items = append(feed.Items, &feeds.Item{
+			ID:          fmt.Sprintf("%s/changes/%d", siteUrl, change.ID),
+			Title:       change.Title,
+			Link:        siteUrl + "/p" + change.Url,
+			Description: description,
+			Author:      change.Author,
+			Published:   change.ChangeDate,
+		})
+	}
+	return feed, nil
+}
+
+func parseDateParam(value string, field string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.NewValidationError("invalid date, use YYYY-MM-DD or RFC 3339", field)
+}
+
+// parseUntilParam parses the exclusive end of a date range. A date without a time
+// includes the whole day.
+func parseUntilParam(value string, field string) (time.Time, error) {
+	t, err := parseDateParam(value, field)
+	if err != nil || t.IsZero() {
+		return t, err
+	}
+	if _, err := time.Parse("2006-01-02", value); err == nil {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"strconv"
+
+	"wikigo/internal/common/apihelper"
+	"wikigo/internal/common/errors"
+	"wikigo/internal/pages"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type RedirectHandler struct {
+	RedirectService *pages.RedirectService
+}
+
+type RedirectRequest struct {
+	FromUrl string `json:"fromUrl" validate:"required,max=100"`
+	ToUrl   string `json:"toUrl" validate:"required,max=500"`
+}
+
+func (h *RedirectHandler) GetRedirects(e echo.Context) error {
+	redirects, err := h.RedirectService.GetRedirects()
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, redirects)
+}
+
+func (h *RedirectHandler) GetRedirect(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid redirect id")
+	}
+	redirect, err := h.RedirectService.GetRedirect(id)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(200, redirect)
+}
+
+func (h *RedirectHandler) CreateRedirect(e echo.Context) error {
+	req := new(RedirectRequest)
+	if err := e.Bind(req); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return errors.BadRequest("invalid request: " + err.Error())
+	}
+	redirect := &pages.Redirect{FromUrl: req.FromUrl, ToUrl: req.ToUrl}
+	if err := h.RedirectService.CreateRedirect(redirect, apihelper.GetUserId(e)); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.JSON(201, redirect)
+}
+
+func (h *RedirectHandler) UpdateRedirect(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid redirect id")
+	}
+	req := new(RedirectRequest)
+	if err := e.Bind(req); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return errors.BadRequest("invalid request: " + err.Error())
+	}
+	redirect := &pages.Redirect{ID: id, FromUrl: req.FromUrl, ToUrl: req.ToUrl}
+	if err := h.RedirectService.UpdateRedirect(redirect); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return h.GetRedirect(e)
+}
+
+func (h *RedirectHandler) DeleteRedirect(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid redirect id")
+	}
+	if err := h.RedirectService.DeleteRedirect(id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return e.NoContent(204)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"wikigo/internal/common/apihelper"
+	"wikigo/internal/common/errors"
+	"wikigo/internal/users"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SessionsHandler struct {
+	SessionService *users.UserSessionService
+	UserService    *users.UserService
+}
+
+type SessionResponse struct {
+	ID         int       `json:"id"`
+	IPAddress  string    `json:"ipAddress"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	IsCurrent  bool      `json:"isCurrent"`
+}
+
+// GetSessions lists the active sessions of the user. The session ID is left out, as it
+// is part of the auth token.
+func (h *SessionsHandler) GetSessions(e echo.Context) error {
+	sessions, err := h.SessionService.ListSessions(apihelper.GetUserId(e))
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	current := apihelper.GetSessionID(e)
+	resp := make([]*SessionResponse, len(sessions))
+	for i, session := range sessions {
+		resp[i] = &SessionResponse{
+			ID:         session.ID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			ExpiresAt:  session.ExpiresAt,
+			LastSeenAt: session.LastSeenAt,
+			IsCurrent:  session.SessionID == current,
+		}
+	}
+	return e.JSON(200, resp)
+}
+
+func (h *SessionsHandler) RevokeSession(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid session id")
+	}
+	if err := h.SessionService.RevokeSession(apihelper.GetUserId(e), id); err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return apihelper.OkMessage(e, "session revoked")
+}
+
+// LogoutUser ends all sessions of a user, who has to log in again.
+func (h *SessionsHandler) LogoutUser(e echo.Context) error {
+	id, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return errors.BadRequest("invalid user id")
+	}
+	user, err := h.UserService.DB.GetUserByID(id)
+	if err != nil || user == nil {
+		return errors.NotFound("user not found")
+	}
+	count, err := h.SessionService.RevokeUserSessions(user.UserName)
+	if err != nil {
+		return apihelper.ReturnErrorResponse(e, err)
+	}
+	return apihelper.OkMessage(e, strconv.Itoa(count)+" sessions revoked")
+}
@@ -11,7 +11,9 @@ import (
 )
 
 type UsersHandler struct {
-	UserService *users.UserService
+	UserService    *users.UserService
+	GroupService   *users.GroupService
+	SessionService *users.UserSessionService
 }
 
 type UserResponse struct {
@@ -109,6 +111,7 @@ type UpdateUserRequest struct {
 	Email       string `json:"email" validate:"required,email,max=100"`
 	Role        string `json:"role" validate:"required,oneof=reader editor admin"`
 	NewPassword string `json:"newPassword"`
+	IsLockedOut *bool  `json:"isLockedOut"`
 }
 
 func (h *UsersHandler) UpdateUser(e echo.Context) error {
@@ -131,9 +134,13 @@ func (h *UsersHandler) UpdateUser(e echo.Context) error {
 	if user == nil {
 		return errors.NotFound("user not found")
 	}
+	oldUserName := user.UserName
 	user.UserName = userReq.UserName
 	user.Email = userReq.Email
 	user.Role = userReq.Role
+	if userReq.IsLockedOut != nil {
+		user.IsLockedOut = *userReq.IsLockedOut
+	}
 	if userReq.NewPassword != "" {
 		if err := user.UpdatePassword(userReq.NewPassword); err != nil {
 			return err
@@ -142,5 +149,16 @@ func (h *UsersHandler) UpdateUser(e echo.Context) error {
 	if err := h.UserService.UpdateUser(user); err != nil {
 		return err
 	}
+	if oldUserName != user.UserName && h.GroupService != nil {
+		if err := h.GroupService.RenameMember(oldUserName, user.UserName); err != nil {
+			return err
+		}
+	}
+	// A locked out or renamed user is logged out everywhere
+	if (user.IsLockedOut || oldUserName != user.UserName) && h.SessionService != nil {
+		if _, err := h.SessionService.RevokeUserSessions(oldUserName); err != nil {
+			return err
+		}
+	}
 	return e.JSON(200, user)
 }
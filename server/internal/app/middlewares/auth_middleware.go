@@ -1,31 +1,52 @@
 package middlewares
 
 import (
+	stderrors "errors"
+	"strings"
+
 	"wikigo/internal/common/apihelper"
 	"wikigo/internal/common/errors"
 	"wikigo/internal/keymgmt"
+	"wikigo/internal/users"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
 type JWT struct {
-	KeyStore *keymgmt.KeyMgmtService
+	KeyStore        *keymgmt.KeyMgmtService
+	ApiTokenService *users.ApiTokenService
+	GroupService    *users.GroupService
+	SessionService  *users.UserSessionService
 }
 
 func (j *JWT) AuthMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(e echo.Context) error {
+			// Only bearer tokens with the API token prefix are handled here. Other headers,
+			// e.g. basic auth or a bearer token of a reverse proxy, fall back to the cookies
+			if scheme, value, _ := strings.Cut(e.Request().Header.Get("Authorization"), " "); strings.EqualFold(scheme, "Bearer") {
+				if value = strings.TrimSpace(value); strings.HasPrefix(value, users.ApiTokenPrefix) {
+					if err := j.authenticateApiToken(e, value); err != nil {
+						return apihelper.ReturnErrorResponse(e, err)
+					}
+					return next(e)
+				}
+			}
 			user, err := e.Cookie("user")
 			if err != nil {
 				return next(e)
 			}
+			// A missing or expired access token is renewed by the client with the refresh
+			// token, so the cookies are kept until then
 			accessToken, err := e.Cookie("token")
 			if err != nil {
-				apihelper.RemoveAuthCookie(e)
-				return next(e)
+				return j.expireToken(e, next)
 			}
 			token, err := j.KeyStore.VerifyJWT(accessToken.Value, "auth")
+			if stderrors.Is(err, jwt.ErrTokenExpired) {
+				return j.expireToken(e, next)
+			}
 			if err != nil || !token.Valid {
 				apihelper.RemoveAuthCookie(e)
 				return next(e)
@@ -35,6 +56,20 @@ func (j *JWT) AuthMiddleware() echo.MiddlewareFunc {
 				apihelper.RemoveAuthCookie(e)
 				return next(e)
 			}
+			// Tokens of revoked or expired sessions, and tokens issued without a session,
+			// are rejected even though their signature is valid
+			if j.SessionService != nil {
+				claims, _ := token.Claims.(jwt.MapClaims)
+				sessionID, _ := claims["jti"].(string)
+				active, err := j.SessionService.ValidateSession(sessionID, userId)
+				if err != nil {
+					return apihelper.ReturnErrorResponse(e, err)
+				}
+				if !active {
+					apihelper.RemoveAuthCookie(e)
+					return next(e)
+				}
+			}
 			e.Set("user", user.Value)
 			e.Set("role", role)
 			e.Set("token", token)
@@ -43,6 +78,39 @@ func (j *JWT) AuthMiddleware() echo.MiddlewareFunc {
 	}
 }
 
+// authenticateApiToken accepts a personal access token sent as a bearer token. The request
+// gets the same context values as a login, with the role granted by the token scope.
+func (j *JWT) authenticateApiToken(e echo.Context, value string) error {
+	if j.ApiTokenService == nil {
+		return errors.Unauthorized("API tokens are not supported")
+	}
+	apiToken, user, err := j.ApiTokenService.Authenticate(value)
+	if err != nil {
+		return errors.Unauthorized("invalid token")
+	}
+	groups := []string{}
+	if j.GroupService != nil {
+		if groups, err = j.GroupService.GetUserGroups(user.UserName); err != nil {
+			return err
+		}
+	}
+	role := apiToken.Role(user.Role)
+	token := &jwt.Token{
+		Claims: jwt.MapClaims{
+			"uid":    user.UserName,
+			"scope":  role,
+			"groups": groups,
+			"exp":    apiToken.ExpiresAt.Unix(),
+		},
+		Valid: true,
+	}
+	e.Set("user", user.UserName)
+	e.Set("role", role)
+	e.Set("token", token)
+	e.Set("apiToken", apiToken)
+	return nil
+}
+
 func (j *JWT) ParseToken(tokenString string) (*jwt.Token, error) {
 	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return j.KeyStore.GetPublicKey("auth")
@@ -61,6 +129,46 @@ func AuthorizeMiddleware() echo.MiddlewareFunc {
 	}
 }
 
+// expireToken marks the request for TokenExpiryMiddleware when the access token can be
+// refreshed. Without a refresh token the login is over and the cookies are removed.
+func (j *JWT) expireToken(e echo.Context, next echo.HandlerFunc) error {
+	if refreshToken, err := e.Cookie("refresh_token"); err != nil || refreshToken.Value == "" {
+		apihelper.RemoveAuthCookie(e)
+		return next(e)
+	}
+	e.Set("tokenExpired", true)
+	return next(e)
+}
+
+// TokenExpiryMiddleware rejects requests whose access token expired with 401 and the
+// TOKEN_EXPIRED code, so the client refreshes the token and retries the request instead
+// of getting the response of an anonymous user. The auth routes are not behind it, as
+// the token is refreshed and the user logged out there.
+func TokenExpiryMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			if e.Get("tokenExpired") != nil {
+				return e.JSON(401, apihelper.NewTokenExpiredError("access token expired"))
+			}
+			return next(e)
+		}
+	}
+}
+
+// NoApiTokenMiddleware rejects requests authenticated with an API token. Account
+// management, such as passwords, passkeys, sessions and tokens, needs a login, so a
+// leaked token cannot be used to take over the account.
+func NoApiTokenMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			if e.Get("apiToken") != nil {
+				return &errors.ForbiddenError{Message: "not allowed with an API token"}
+			}
+			return next(e)
+		}
+	}
+}
+
 func AdminMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(e echo.Context) error {
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"wikigo/internal/users"
+
+	"github.com/dannyswat/filedb"
+)
+
+// apiTokenEntity stores the token hash, which ApiToken leaves out of its JSON so that
+// it never ends up in a response.
+type apiTokenEntity struct {
+	users.ApiToken
+	TokenHash string `json:"tokenHash"`
+}
+
+func (e *apiTokenEntity) GetValue(field string) string {
+	if field == "TokenHash" {
+		return e.TokenHash
+	}
+	return e.ApiToken.GetValue(field)
+}
+
+func newApiTokenEntity(token *users.ApiToken) *apiTokenEntity {
+	return &apiTokenEntity{ApiToken: *token, TokenHash: token.TokenHash}
+}
+
+func (e *apiTokenEntity) toApiToken() *users.ApiToken {
+	token := e.ApiToken
+	token.TokenHash = e.TokenHash
+	return &token
+}
+
+type apiTokenDB struct {
+	db filedb.FileDB[*apiTokenEntity]
+}
+
+func NewApiTokenDB(path string) users.ApiTokenRepository {
+	return &apiTokenDB{
+		db: filedb.NewFileDB[*apiTokenEntity](path, []filedb.FileIndexConfig{
+			{Field: "UserName", Unique: false},
+			{Field: "TokenHash", Unique: true},
+		}),
+	}
+}
+
+func (t *apiTokenDB) Init() error {
+	return t.db.Init()
+}
+
+func (t *apiTokenDB) GetByID(id int) (*users.ApiToken, error) {
+	entity, err := t.db.Find(id)
+	if err != nil || entity == nil {
+		return nil, err
+	}
+	return entity.toApiToken(), nil
+}
+
+func (t *apiTokenDB) GetByTokenHash(hash string) (*users.ApiToken, error) {
+	entities, err := t.db.List("TokenHash", hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(entities) == 0 {
+		return nil, nil
+	}
+	return entities[0].toApiToken(), nil
+}
+
+func (t *apiTokenDB) GetByUserName(username string) ([]*users.ApiToken, error) {
+	entities, err := t.db.List("UserName", username)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]*users.ApiToken, len(entities))
+	for i, entity := range entities {
+		tokens[i] = entity.toApiToken()
+	}
+	return tokens, nil
+}
+
+func (t *apiTokenDB) CreateToken(token *users.ApiToken) error {
+	entity := newApiTokenEntity(token)
+	if err := t.db.Insert(entity); err != nil {
+		return err
+	}
+	token.ID = entity.ID
+	return nil
+}
+
+func (t *apiTokenDB) UpdateToken(token *users.ApiToken) error {
+	return t.db.Update(newApiTokenEntity(token))
+}
+
+func (t *apiTokenDB) DeleteToken(id int) error {
+	return t.db.Delete(id)
+}
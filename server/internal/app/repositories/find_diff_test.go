@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     []string
+		new     []string
+		added   []string
+		removed []string
+	}{
+		{"Unchanged", []string{"a", "b"}, []string{"b", "a"}, nil, nil},
+		{"Added", []string{"a"}, []string{"a", "b"}, []string{"b"}, nil},
+		{"Removed", []string{"a", "b"}, []string{"a"}, nil, []string{"b"}},
+		{"Replaced", []string{"a", "b"}, []string{"b", "c"}, []string{"c"}, []string{"a"}},
+		{"All removed", []string{"a"}, nil, nil, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := findDiff(tt.old, tt.new)
+			if !reflect.DeepEqual(added, tt.added) || !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("findDiff(%v, %v) = %v, %v, expected %v, %v", tt.old, tt.new, added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"strings"
+
+	"wikigo/internal/users"
+
+	"github.com/dannyswat/filedb"
+)
+
+type groupDB struct {
+	db filedb.FileDB[*users.Group]
+}
+
+func NewGroupDB(path string) users.GroupRepository {
+	return &groupDB{
+		db: filedb.NewFileDB[*users.Group](path, []filedb.FileIndexConfig{
+			{Field: "Name", Unique: true},
+		}),
+	}
+}
+
+func (g *groupDB) Init() error {
+	return g.db.Init()
+}
+
+func (g *groupDB) GetGroupByID(id int) (*users.Group, error) {
+	return g.db.Find(id)
+}
+
+func (g *groupDB) GetGroupByName(name string) (*users.Group, error) {
+	groups, err := g.db.List("Name", strings.ToLower(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	return groups[0], nil
+}
+
+func (g *groupDB) ListAll() ([]*users.Group, error) {
+	return g.db.ListAll()
+}
+
+func (g *groupDB) CreateGroup(group *users.Group) error {
+	return g.db.Insert(group)
+}
+
+func (g *groupDB) UpdateGroup(group *users.Group) error {
+	return g.db.Update(group)
+}
+
+func (g *groupDB) DeleteGroup(id int) error {
+	return g.db.Delete(id)
+}
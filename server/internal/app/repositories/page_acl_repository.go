@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"strconv"
+
+	"wikigo/internal/pages"
+
+	"github.com/dannyswat/filedb"
+)
+
+type pageAclDB struct {
+	db filedb.FileDB[*pages.PageAcl]
+}
+
+func NewPageAclDB(path string) pages.PageAclRepository {
+	return &pageAclDB{
+		db: filedb.NewFileDB[*pages.PageAcl](path, []filedb.FileIndexConfig{
+			{Field: "PageID", Unique: true},
+		}),
+	}
+}
+
+func (r *pageAclDB) Init() error {
+	return r.db.Init()
+}
+
+func (r *pageAclDB) GetPageAcl(pageId int) (*pages.PageAcl, error) {
+	acls, err := r.db.List("PageID", strconv.Itoa(pageId))
+	if err != nil {
+		return nil, err
+	}
+	if len(acls) == 0 {
+		return nil, nil
+	}
+	return acls[0], nil
+}
+
+func (r *pageAclDB) ListPageAcls() ([]*pages.PageAcl, error) {
+	return r.db.ListAll()
+}
+
+func (r *pageAclDB) SavePageAcl(acl *pages.PageAcl) error {
+	existing, err := r.GetPageAcl(acl.PageID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return r.db.Insert(acl)
+	}
+	acl.ID = existing.ID
+	return r.db.Update(acl)
+}
+
+func (r *pageAclDB) DeletePageAcl(pageId int) error {
+	existing, err := r.GetPageAcl(pageId)
+	if err != nil || existing == nil {
+		return err
+	}
+	return r.db.Delete(existing.ID)
+}
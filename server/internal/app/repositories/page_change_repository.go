@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"strconv"
+	"time"
+
+	"wikigo/internal/pages"
+
+	"github.com/dannyswat/filedb"
+)
+
+type pageChangeDB struct {
+	db filedb.FileDB[*pages.PageChange]
+}
+
+func NewPageChangeDB(path string) pages.PageChangeRepository {
+	return &pageChangeDB{
+		db: filedb.NewFileDB[*pages.PageChange](path, []filedb.FileIndexConfig{
+			{Field: "Author", Unique: false, Include: []string{"PageID", "ParentID", "Action", "Title", "Url", "Comment", "ChangeDate", "IsProtected"}},
+		}),
+	}
+}
+
+func (p *pageChangeDB) Init() error {
+	return p.db.Init()
+}
+
+func (p *pageChangeDB) AddChange(change *pages.PageChange) error {
+	return p.db.Insert(change)
+}
+
+func (p *pageChangeDB) ListChanges() ([]*pages.PageChange, error) {
+	entries, err := p.db.ListAllIndexFields("Author")
+	if err != nil {
+		return nil, err
+	}
+	return getPageChangesFromIndexEntries(entries), nil
+}
+
+func (p *pageChangeDB) ListChangesByAuthor(author string) ([]*pages.PageChange, error) {
+	entries, err := p.db.ListIndexFields("Author", author)
+	if err != nil {
+		return nil, err
+	}
+	return getPageChangesFromIndexEntries(entries), nil
+}
+
+func getPageChangesFromIndexEntries(entries []*filedb.IndexEntry) []*pages.PageChange {
+	changes := make([]*pages.PageChange, len(entries))
+	for i, entry := range entries {
+		var parentId *int
+		if pid, err := strconv.Atoi(entry.Others["ParentID"]); err == nil {
+			parentId = &pid
+		}
+		pageId, _ := strconv.Atoi(entry.Others["PageID"])
+		changeDate, _ := time.Parse(time.RFC3339Nano, entry.Others["ChangeDate"])
+		isProtected, _ := strconv.ParseBool(entry.Others["IsProtected"])
+		changes[i] = &pages.PageChange{
+			ID:          entry.ID,
+			PageID:      pageId,
+			ParentID:    parentId,
+			Action:      pages.ChangeAction(entry.Others["Action"]),
+			Title:       entry.Others["Title"],
+			Url:         entry.Others["Url"],
+			Author:      entry.Value,
+			Comment:     entry.Others["Comment"],
+			ChangeDate:  changeDate,
+			IsProtected: isProtected,
+		}
+	}
+	return changes
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"slices"
+
+	"wikigo/internal/pages"
+
+	"github.com/dannyswat/filedb"
+)
+
+type PageLinkListRepository struct {
+	db filedb.FileDB[*pages.PageLinkList]
+}
+
+func NewPageLinkListRepository(path string) *PageLinkListRepository {
+	return &PageLinkListRepository{
+		db: filedb.NewFileDB[*pages.PageLinkList](path, []filedb.FileIndexConfig{
+			{Field: "Url", Unique: true},
+		}),
+	}
+}
+
+func (r *PageLinkListRepository) Init() error {
+	return r.db.Init()
+}
+
+func (r *PageLinkListRepository) GetPageLinkList(url string) (*pages.PageLinkList, error) {
+	lists, err := r.db.List("Url", url)
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, nil
+	}
+	return lists[0], nil
+}
+
+func (r *PageLinkListRepository) UpdatePageLinkLists(urls, oldUrls []string, pageId int) error {
+	added, removed := findDiff(oldUrls, urls)
+
+	for _, url := range added {
+		list, err := r.GetPageLinkList(url)
+		if err != nil {
+			return err
+		}
+		if list == nil {
+			if err := r.db.Insert(&pages.PageLinkList{Url: url, PageIds: []int{pageId}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if slices.Contains(list.PageIds, pageId) {
+			continue
+		}
+		list.PageIds = append(list.PageIds, pageId)
+		if err := r.db.Update(list); err != nil {
+			return err
+		}
+	}
+
+	for _, url := range removed {
+		list, err := r.GetPageLinkList(url)
+		if err != nil {
+			return err
+		}
+		if list == nil {
+			continue
+		}
+		list.PageIds = slices.DeleteFunc(list.PageIds, func(id int) bool { return id == pageId })
+		if len(list.PageIds) == 0 {
+			err = r.db.Delete(list.ID)
+		} else {
+			err = r.db.Update(list)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PageLinkListRepository) DeleteAll() error {
+	entries, err := r.db.ListAll()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := r.db.Delete(entry.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDiff returns the urls only in newUrls and the urls only in oldUrls.
+func findDiff(oldUrls, newUrls []string) (added, removed []string) {
+	oldSet := make(map[string]struct{}, len(oldUrls))
+	for _, url := range oldUrls {
+		oldSet[url] = struct{}{}
+	}
+
+	for _, url := range newUrls {
+		if _, exists := oldSet[url]; !exists {
+			added = append(added, url)
+		}
+	}
+
+	newSet := make(map[string]struct{}, len(newUrls))
+	for _, url := range newUrls {
+		newSet[url] = struct{}{}
+	}
+	for _, url := range oldUrls {
+		if _, exists := newSet[url]; !exists {
+			removed = append(removed, url)
+		}
+	}
+
+	return added, removed
+}
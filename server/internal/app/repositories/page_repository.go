@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"strconv"
+	"time"
 
 	"wikigo/internal/pages"
 
@@ -16,8 +17,8 @@ func NewPageDB(path string) pages.PageRepository {
 	return &pageDB{
 		db: filedb.NewFileDB[*pages.Page](path, []filedb.FileIndexConfig{
 			{Field: "Url", Unique: true},
-			{Field: "CreatedBy", Unique: false, Include: []string{"Url", "ParentID", "Title", "IsPinned", "IsProtected", "SortChildrenDesc"}},
-			{Field: "ParentID", Unique: false, Include: []string{"Url", "Title", "IsPinned", "IsProtected", "SortChildrenDesc"}},
+			{Field: "CreatedBy", Unique: false, Include: []string{"Url", "ParentID", "Title", "IsPinned", "IsProtected", "SortChildrenDesc", "DeletedAt"}},
+			{Field: "ParentID", Unique: false, Include: []string{"Url", "Title", "IsPinned", "IsProtected", "SortChildrenDesc", "DeletedAt"}},
 		}),
 	}
 }
@@ -51,7 +52,7 @@ func (p *pageDB) GetPagesByAuthor(author string) ([]*pages.PageMeta, error) {
 		return nil, err
 	}
 	pagesResult := GetPageMetasFromIndexEntries(entries, "CreatedBy")
-	return pagesResult, nil
+	return excludeDeletedPages(pagesResult), nil
 }
 
 func (p *pageDB) GetPagesByParentID(parentID *int) ([]*pages.PageMeta, error) {
@@ -64,7 +65,7 @@ func (p *pageDB) GetPagesByParentID(parentID *int) ([]*pages.PageMeta, error) {
 		return nil, err
 	}
 	pagesResult := GetPageMetasFromIndexEntries(entries, "ParentID")
-	return pagesResult, nil
+	return excludeDeletedPages(pagesResult), nil
 }
 
 func (p *pageDB) IsPageCyclical(page *pages.Page) (bool, error) {
@@ -102,7 +103,7 @@ func (p *pageDB) GetAllPages(includeProtected bool) ([]*pages.PageMeta, error) {
 	if err != nil {
 		return nil, err
 	}
-	pagesResult := GetPageMetasFromIndexEntries(entries, "CreatedBy")
+	pagesResult := excludeDeletedPages(GetPageMetasFromIndexEntries(entries, "CreatedBy"))
 	if includeProtected {
 		return pagesResult, nil
 	}
@@ -115,6 +116,30 @@ func (p *pageDB) GetAllPages(includeProtected bool) ([]*pages.PageMeta, error) {
 	return result, nil
 }
 
+func (p *pageDB) GetDeletedPages() ([]*pages.PageMeta, error) {
+	entries, err := p.db.ListAllIndexFields("CreatedBy")
+	if err != nil {
+		return nil, err
+	}
+	var result []*pages.PageMeta
+	for _, page := range GetPageMetasFromIndexEntries(entries, "CreatedBy") {
+		if page.DeletedAt != nil {
+			result = append(result, page)
+		}
+	}
+	return result, nil
+}
+
+func excludeDeletedPages(pagesResult []*pages.PageMeta) []*pages.PageMeta {
+	result := make([]*pages.PageMeta, 0, len(pagesResult))
+	for _, page := range pagesResult {
+		if page.DeletedAt == nil {
+			result = append(result, page)
+		}
+	}
+	return result
+}
+
 func (p *pageDB) CreatePage(page *pages.Page) error {
 	return p.db.Insert(page)
 }
@@ -158,6 +183,10 @@ func GetPageMetaFromIndexEntry(entry *filedb.IndexEntry, field string) *pages.Pa
 	if desc, err := strconv.ParseBool(entry.Others["SortChildrenDesc"]); err == nil {
 		sortChildrenDesc = desc
 	}
+	var deletedAt *time.Time
+	if deleted, err := time.Parse(time.RFC3339Nano, entry.Others["DeletedAt"]); err == nil {
+		deletedAt = &deleted
+	}
 	return &pages.PageMeta{
 		ID:               entry.ID,
 		ParentID:         parentId,
@@ -166,5 +195,6 @@ func GetPageMetaFromIndexEntry(entry *filedb.IndexEntry, field string) *pages.Pa
 		IsPinned:         isPinned,
 		IsProtected:      isProtected,
 		SortChildrenDesc: sortChildrenDesc,
+		DeletedAt:        deletedAt,
 	}
 }
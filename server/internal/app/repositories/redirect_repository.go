@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"wikigo/internal/pages"
+
+	"github.com/dannyswat/filedb"
+)
+
+type redirectDB struct {
+	db filedb.FileDB[*pages.Redirect]
+}
+
+func NewRedirectDB(path string) pages.RedirectRepository {
+	return &redirectDB{
+		db: filedb.NewFileDB[*pages.Redirect](path, []filedb.FileIndexConfig{
+			{Field: "FromUrl", Unique: true},
+			{Field: "ToUrl", Unique: false},
+		}),
+	}
+}
+
+func (r *redirectDB) Init() error {
+	return r.db.Init()
+}
+
+func (r *redirectDB) GetRedirectByID(id int) (*pages.Redirect, error) {
+	return r.db.Find(id)
+}
+
+func (r *redirectDB) GetRedirectByFromUrl(url string) (*pages.Redirect, error) {
+	redirects, err := r.db.List("FromUrl", url)
+	if err != nil {
+		return nil, err
+	}
+	if len(redirects) == 0 {
+		return nil, nil
+	}
+	return redirects[0], nil
+}
+
+func (r *redirectDB) GetRedirectsByToUrl(url string) ([]*pages.Redirect, error) {
+	return r.db.List("ToUrl", url)
+}
+
+func (r *redirectDB) ListRedirects() ([]*pages.Redirect, error) {
+	return r.db.ListAll()
+}
+
+func (r *redirectDB) CreateRedirect(redirect *pages.Redirect) error {
+	return r.db.Insert(redirect)
+}
+
+func (r *redirectDB) UpdateRedirect(redirect *pages.Redirect) error {
+	return r.db.Update(redirect)
+}
+
+func (r *redirectDB) DeleteRedirect(id int) error {
+	return r.db.Delete(id)
+}
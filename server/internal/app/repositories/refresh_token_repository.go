@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"wikigo/internal/users"
+
+	"github.com/dannyswat/filedb"
+)
+
+type refreshTokenDB struct {
+	db filedb.FileDB[*users.RefreshToken]
+}
+
+func NewRefreshTokenDB(path string) users.RefreshTokenRepository {
+	return &refreshTokenDB{
+		db: filedb.NewFileDB[*users.RefreshToken](path, []filedb.FileIndexConfig{
+			{Field: "SessionID", Unique: false},
+			{Field: "TokenHash", Unique: true},
+		}),
+	}
+}
+
+func (t *refreshTokenDB) Init() error {
+	return t.db.Init()
+}
+
+func (t *refreshTokenDB) GetByTokenHash(hash string) (*users.RefreshToken, error) {
+	tokens, err := t.db.List("TokenHash", hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return tokens[0], nil
+}
+
+func (t *refreshTokenDB) GetBySessionID(sessionID string) ([]*users.RefreshToken, error) {
+	return t.db.List("SessionID", sessionID)
+}
+
+func (t *refreshTokenDB) CreateToken(token *users.RefreshToken) error {
+	return t.db.Insert(token)
+}
+
+func (t *refreshTokenDB) UpdateToken(token *users.RefreshToken) error {
+	return t.db.Update(token)
+}
+
+func (t *refreshTokenDB) DeleteToken(id int) error {
+	return t.db.Delete(id)
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"sort"
 	"strconv"
 
 	"wikigo/internal/revisions"
@@ -20,6 +21,7 @@ func NewRevisionRepository[T interface{}](path string) revisions.RevisionReposit
 	return &RevisionRepository[T]{
 		db: filedb.NewFileDB[*revisions.Revision[T]](path, []filedb.FileIndexConfig{
 			{Field: "RecordID", Unique: false},
+			{Field: "Author", Unique: false},
 		}),
 	}
 }
@@ -28,6 +30,10 @@ func (r *RevisionRepository[T]) Init() error {
 	return r.db.Init()
 }
 
+func (r *RevisionRepository[T]) GetRevision(id int) (*revisions.Revision[T], error) {
+	return r.db.Find(id)
+}
+
 func (r *RevisionRepository[T]) GetLatestRevision(recordID int) (*revisions.Revision[T], error) {
 	entries, err := r.db.ListIndexFields("RecordID", strconv.Itoa(recordID))
 	if err != nil {
@@ -48,6 +54,85 @@ func (r *RevisionRepository[T]) GetLatestRevision(recordID int) (*revisions.Revi
 	return r.db.Find(latest.ID)
 }
 
+func (r *RevisionRepository[T]) ListRevisions(recordID int, offset, limit int) ([]*revisions.Revision[T], int, error) {
+	entries, err := r.db.ListIndexFields("RecordID", strconv.Itoa(recordID))
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.findPage(entries, offset, limit)
+}
+
+func (r *RevisionRepository[T]) ListRevisionsByAuthor(author string, offset, limit int, visible func(*revisions.Revision[T]) bool) ([]*revisions.Revision[T], int, error) {
+	entries, err := r.db.ListIndexFields("Author", author)
+	if err != nil {
+		return nil, 0, err
+	}
+	if visible == nil {
+		return r.findPage(entries, offset, limit)
+	}
+	// Every revision has to be read to know whether it is visible, so the total only
+	// counts the revisions the caller can see.
+	sortNewestFirst(entries)
+	result := make([]*revisions.Revision[T], 0)
+	total := 0
+	for _, entry := range entries {
+		revision, err := r.db.Find(entry.ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if revision == nil || !visible(revision) {
+			continue
+		}
+		if total >= offset && (limit <= 0 || len(result) < limit) {
+			result = append(result, revision)
+		}
+		total++
+	}
+	return result, total, nil
+}
+
+func (r *RevisionRepository[T]) findPage(entries []*filedb.IndexEntry, offset, limit int) ([]*revisions.Revision[T], int, error) {
+	total := len(entries)
+	sortNewestFirst(entries)
+	if offset >= total {
+		return []*revisions.Revision[T]{}, total, nil
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	result := make([]*revisions.Revision[T], 0, end-offset)
+	for _, entry := range entries[offset:end] {
+		revision, err := r.db.Find(entry.ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, revision)
+	}
+	return result, total, nil
+}
+
 func (r *RevisionRepository[T]) AddRevision(e *revisions.Revision[T]) error {
 	return r.db.Insert(e)
 }
+
+func (r *RevisionRepository[T]) DeleteRevisions(recordID int) error {
+	entries, err := r.db.ListIndexFields("RecordID", strconv.Itoa(recordID))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := r.db.Delete(entry.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortNewestFirst orders revision index entries from the newest to the oldest. Revision
+// IDs are assigned incrementally, so the highest ID is the newest.
+func sortNewestFirst(entries []*filedb.IndexEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"strconv"
+
+	"wikigo/internal/pages"
+
+	"github.com/dannyswat/filedb"
+)
+
+type SearchDocumentRepository struct {
+	db filedb.FileDB[*pages.SearchDocument]
+}
+
+func NewSearchDocumentRepository(path string) *SearchDocumentRepository {
+	return &SearchDocumentRepository{
+		db: filedb.NewFileDB[*pages.SearchDocument](path, []filedb.FileIndexConfig{
+			{Field: "PageID", Unique: true},
+		}),
+	}
+}
+
+func (r *SearchDocumentRepository) Init() error {
+	return r.db.Init()
+}
+
+func (r *SearchDocumentRepository) GetSearchDocument(pageId int) (*pages.SearchDocument, error) {
+	docs, err := r.db.List("PageID", strconv.Itoa(pageId))
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	return docs[0], nil
+}
+
+func (r *SearchDocumentRepository) ListSearchDocuments() ([]*pages.SearchDocument, error) {
+	return r.db.ListAll()
+}
+
+func (r *SearchDocumentRepository) SaveSearchDocument(doc *pages.SearchDocument) error {
+	existing, err := r.GetSearchDocument(doc.PageID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return r.db.Insert(doc)
+	}
+	doc.ID = existing.ID
+	return r.db.Update(doc)
+}
+
+func (r *SearchDocumentRepository) DeleteSearchDocument(pageId int) error {
+	existing, err := r.GetSearchDocument(pageId)
+	if err != nil || existing == nil {
+		return err
+	}
+	return r.db.Delete(existing.ID)
+}
+
+func (r *SearchDocumentRepository) DeleteAll() error {
+	docs, err := r.db.ListAll()
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := r.db.Delete(doc.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
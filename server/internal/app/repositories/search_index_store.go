@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"wikigo/internal/pages"
+)
+
+// SearchIndexStore keeps each search index in its own term list and document
+// directories. The unnamed index uses the directories of earlier versions.
+type SearchIndexStore struct {
+	Path string
+}
+
+func NewSearchIndexStore(path string) *SearchIndexStore {
+	return &SearchIndexStore{Path: path}
+}
+
+func (r *SearchIndexStore) statePath() string {
+	return filepath.Join(r.Path, "search_index.json")
+}
+
+func (r *SearchIndexStore) indexPaths(name string) (string, string) {
+	if name == "" {
+		return r.Path + "/search_terms", r.Path + "/search_documents"
+	}
+	return r.Path + "/search_terms_" + name, r.Path + "/search_documents_" + name
+}
+
+func (r *SearchIndexStore) GetState() (*pages.SearchIndexState, error) {
+	data, err := os.ReadFile(r.statePath())
+	if os.IsNotExist(err) {
+		return &pages.SearchIndexState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state pages.SearchIndexState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveState writes the state to a temporary file and renames it, so a crash
+// leaves either the old or the new state.
+func (r *SearchIndexStore) SaveState(state *pages.SearchIndexState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := r.statePath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, r.statePath())
+}
+
+func (r *SearchIndexStore) OpenIndex(name string) (pages.SearchTermListRepository, pages.SearchDocumentRepository, error) {
+	termsPath, docsPath := r.indexPaths(name)
+	terms := NewSearchTermListRepository(termsPath)
+	if err := terms.Init(); err != nil {
+		return nil, nil, err
+	}
+	docs := NewSearchDocumentRepository(docsPath)
+	if err := docs.Init(); err != nil {
+		return nil, nil, err
+	}
+	return terms, docs, nil
+}
+
+func (r *SearchIndexStore) DeleteIndex(name string) error {
+	termsPath, docsPath := r.indexPaths(name)
+	if err := os.RemoveAll(filepath.FromSlash(termsPath)); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.FromSlash(docsPath))
+}
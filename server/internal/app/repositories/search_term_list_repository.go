@@ -33,68 +33,63 @@ func (r *SearchTermListRepository) GetSearchTermList(term string) (*pages.Search
 	return lists[0], nil
 }
 
-func (r *SearchTermListRepository) UpdateSearchTermLists(terms, oldTerms []string, pageId int) error {
-	added, removed := findDiff(oldTerms, terms)
-	listToInsert := make([]*pages.SearchTermList, 0)
-	listToUpdate := make([]*pages.SearchTermList, 0)
-	listToDelete := make([]*pages.SearchTermList, 0)
+// ListTerms returns all indexed terms. Only the term index is read.
+func (r *SearchTermListRepository) ListTerms() ([]string, error) {
+	entries, err := r.db.ListAllIndexFields("Term")
+	if err != nil {
+		return nil, err
+	}
+	terms := make([]string, len(entries))
+	for i, entry := range entries {
+		terms[i] = entry.Value
+	}
+	return terms, nil
+}
 
-	for _, term := range added {
+// UpdateSearchTermLists stores the postings of a page and removes the page from
+// the old terms it no longer contains.
+func (r *SearchTermListRepository) UpdateSearchTermLists(postings map[string]*pages.TermPosting, oldTerms []string, pageId int) error {
+	for term, posting := range postings {
 		list, err := r.GetSearchTermList(term)
 		if err != nil {
 			return err
 		}
 		if list == nil {
-			list = &pages.SearchTermList{Term: term, PageIds: []int{}}
-			listToInsert = append(listToInsert, list)
-		} else {
-			listToUpdate = append(listToUpdate, list)
+			list = &pages.SearchTermList{Term: term, PageIds: []int{pageId}, Postings: []*pages.TermPosting{posting}}
+			if err := r.db.Insert(list); err != nil {
+				return err
+			}
+			continue
+		}
+		if !list.SetPosting(posting) {
+			continue
+		}
+		if err := r.db.Update(list); err != nil {
+			return err
 		}
-		list.PageIds = append(list.PageIds, pageId)
 	}
 
-	for _, term := range removed {
+	for _, term := range oldTerms {
+		if _, exists := postings[term]; exists {
+			continue
+		}
 		list, err := r.GetSearchTermList(term)
 		if err != nil {
 			return err
 		}
-		if list != nil {
-			for i, id := range list.PageIds {
-				if id == pageId {
-					list.PageIds = append(list.PageIds[:i], list.PageIds[i+1:]...)
-					break
-				}
-			}
-			if len(list.PageIds) == 0 {
-				listToDelete = append(listToDelete, list)
-			} else {
-				listToUpdate = append(listToUpdate, list)
-			}
-		}
-	}
-
-	if len(listToInsert) > 0 {
-		for _, list := range listToInsert {
-			if err := r.db.Insert(list); err != nil {
-				return err
-			}
+		if list == nil {
+			continue
 		}
-	}
-	if len(listToUpdate) > 0 {
-		for _, list := range listToUpdate {
-			if err := r.db.Update(list); err != nil {
-				return err
-			}
+		list.RemovePosting(pageId)
+		if len(list.PageIds) == 0 {
+			err = r.db.Delete(list.ID)
+		} else {
+			err = r.db.Update(list)
 		}
-	}
-	if len(listToDelete) > 0 {
-		for _, list := range listToDelete {
-			if err := r.db.Delete(list.ID); err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 	}
-
 	return nil
 }
 
@@ -110,24 +105,3 @@ func (r *SearchTermListRepository) DeleteAll() error {
 	}
 	return nil
 }
-
-func findDiff(oldTerms, newTerms []string) (added, removed []string) {
-	oldSet := make(map[string]struct{}, len(oldTerms))
-	for _, term := range oldTerms {
-		oldSet[term] = struct{}{}
-	}
-
-	for _, term := range newTerms {
-		if _, exists := oldSet[term]; !exists {
-			added = append(added, term)
-		}
-	}
-
-	for _, term := range oldTerms {
-		if _, exists := oldSet[term]; !exists {
-			removed = append(removed, term)
-		}
-	}
-
-	return added, removed
-}
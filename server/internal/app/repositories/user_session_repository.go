@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"wikigo/internal/users"
+
+	"github.com/dannyswat/filedb"
+)
+
+type userSessionDB struct {
+	db filedb.FileDB[*users.UserSession]
+}
+
+func NewUserSessionDB(path string) users.UserSessionRepository {
+	return &userSessionDB{
+		db: filedb.NewFileDB[*users.UserSession](path, []filedb.FileIndexConfig{
+			{Field: "SessionID", Unique: true},
+			{Field: "UserName", Unique: false},
+		}),
+	}
+}
+
+func (s *userSessionDB) Init() error {
+	return s.db.Init()
+}
+
+func (s *userSessionDB) GetByID(id int) (*users.UserSession, error) {
+	return s.db.Find(id)
+}
+
+func (s *userSessionDB) GetBySessionID(sessionID string) (*users.UserSession, error) {
+	sessions, err := s.db.List("SessionID", sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	return sessions[0], nil
+}
+
+func (s *userSessionDB) GetByUserName(username string) ([]*users.UserSession, error) {
+	return s.db.List("UserName", username)
+}
+
+func (s *userSessionDB) CreateSession(session *users.UserSession) error {
+	return s.db.Insert(session)
+}
+
+func (s *userSessionDB) UpdateSession(session *users.UserSession) error {
+	return s.db.Update(session)
+}
+
+func (s *userSessionDB) DeleteSession(id int) error {
+	return s.db.Delete(id)
+}
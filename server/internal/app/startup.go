@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"wikigo/internal/app/handlers"
 	"wikigo/internal/app/middlewares"
@@ -33,10 +34,18 @@ type WikiStartUp struct {
 
 	dbManager            DBManager
 	userService          *users.UserService
+	groupService         *users.GroupService
+	apiTokenService      *users.ApiTokenService
+	sessionService       *users.UserSessionService
 	pageService          *pages.PageService
 	keyStore             *keymgmt.KeyMgmtService
 	pageRevisionService  *revisions.RevisionService[*pages.Page]
 	searchService        *pages.SearchService
+	pageDiffService      *pages.PageDiffService
+	redirectService      *pages.RedirectService
+	linkService          *pages.LinkService
+	recentChangeService  *pages.RecentChangeService
+	pageAccessService    *pages.PageAccessService
 	settingService       *setting.SettingService
 	htmlPolicy           *bluemonday.Policy
 	fileManager          filemanager.FileManager
@@ -46,7 +55,12 @@ type WikiStartUp struct {
 	uploadHandler        *handlers.UploadHandler
 	fileHandler          *handlers.FileHandler
 	usersHandler         *handlers.UsersHandler
+	groupsHandler        *handlers.GroupsHandler
+	apiTokensHandler     *handlers.ApiTokensHandler
+	sessionsHandler      *handlers.SessionsHandler
 	settingHandler       *handlers.SettingHandler
+	recentChangesHandler *handlers.RecentChangesHandler
+	redirectHandler      *handlers.RedirectHandler
 	jwt                  *middlewares.JWT
 	reactPage            *pages.ReactPageMeta
 	validator            *validator.Validate
@@ -93,6 +107,18 @@ func (s *WikiStartUp) Setup() error {
 		DB:       s.dbManager.Users(),
 		DeviceDB: s.dbManager.UserDevices(),
 	}
+	s.groupService = &users.GroupService{
+		DB:     s.dbManager.Groups(),
+		UserDB: s.dbManager.Users(),
+	}
+	s.apiTokenService = &users.ApiTokenService{
+		DB:     s.dbManager.ApiTokens(),
+		UserDB: s.dbManager.Users(),
+	}
+	s.sessionService = &users.UserSessionService{
+		DB:        s.dbManager.UserSessions(),
+		RefreshDB: s.dbManager.RefreshTokens(),
+	}
 	s.settingService = &setting.SettingService{
 		DB:            s.dbManager.Settings(),
 		Cache:         s.SettingCache,
@@ -111,11 +137,41 @@ func (s *WikiStartUp) Setup() error {
 	s.searchService = &pages.SearchService{
 		PageRepository:           s.dbManager.Pages(),
 		SearchTermListRepository: s.dbManager.SearchTerms(),
+		SearchDocumentRepository: s.dbManager.SearchDocuments(),
+		IndexStore:               s.dbManager.SearchIndexes(),
+	}
+	s.searchService.SetLanguage(siteSetting.Language)
+	s.searchService.SetIndexAttachments(siteSetting.IndexAttachments)
+	s.settingService.OnUpdated = s.onSettingUpdated
+	s.pageDiffService = &pages.PageDiffService{
+		DB:              s.dbManager.Pages(),
+		RevisionService: s.pageRevisionService,
+	}
+	s.redirectService = &pages.RedirectService{
+		Repository:     s.dbManager.Redirects(),
+		PageRepository: s.dbManager.Pages(),
+	}
+	s.linkService = &pages.LinkService{
+		PageLinkListRepository: s.dbManager.PageLinks(),
+		PageRepository:         s.dbManager.Pages(),
+		RedirectService:        s.redirectService,
+	}
+	s.recentChangeService = &pages.RecentChangeService{
+		Repository:     s.dbManager.PageChanges(),
+		PageRepository: s.dbManager.Pages(),
+	}
+	s.pageAccessService = &pages.PageAccessService{
+		Repository:     s.dbManager.PageAcls(),
+		PageRepository: s.dbManager.Pages(),
 	}
 	s.pageService = &pages.PageService{
 		DB:              s.dbManager.Pages(),
 		RevisionService: s.pageRevisionService,
 		SearchService:   s.searchService,
+		ChangeService:   s.recentChangeService,
+		RedirectService: s.redirectService,
+		LinkService:     s.linkService,
+		AccessService:   s.pageAccessService,
 	}
 
 	err = s.keyStore.Init()
@@ -131,6 +187,10 @@ func (s *WikiStartUp) Setup() error {
 		return err
 	}
 	s.fileManager.Init()
+	s.linkService.FileManager = s.fileManager
+	s.searchService.FileManager = s.fileManager
+	logIfError(s.searchService.RebuildSearchIndexIfOutdated())
+	go s.repairSearchIndex()
 	reactFile, err := os.ReadFile(filepath.FromSlash("public/index.html"))
 	if err == nil {
 		s.reactPage = pages.GetReactPageMeta(string(reactFile))
@@ -143,6 +203,7 @@ func (s *WikiStartUp) Setup() error {
 	s.fido2Setting = fido2Setting
 	s.loginRateLimiter = apihelper.NewRateLimiter(5, 1) // 5 requests per minute, refill 1 token per minute
 	s.imageResizer = images.NewImageResizer(100, 100, images.ResizeModeFit)
+	go s.purgeExpiredPages()
 	return nil
 }
 
@@ -163,11 +224,22 @@ func (s *WikiStartUp) RegisterHandlers(e *echo.Echo) {
 		SearchService:       s.searchService,
 		HtmlPolicy:          s.htmlPolicy,
 		PageRevisionService: s.pageRevisionService,
+		PageDiffService:     s.pageDiffService,
+		RedirectService:     s.redirectService,
+		LinkService:         s.linkService,
+		AccessService:       s.pageAccessService,
 		ReactPage:           s.reactPage,
 	}
+	tokenIssuer := &handlers.AuthTokenIssuer{
+		KeyStore:       s.keyStore,
+		UserService:    s.userService,
+		GroupService:   s.groupService,
+		SessionService: s.sessionService,
+	}
 	s.authHandler = &handlers.AuthHandler{
 		UserService: s.userService,
 		KeyStore:    s.keyStore,
+		TokenIssuer: tokenIssuer,
 		RateLimiter: s.loginRateLimiter,
 	}
 
@@ -187,6 +259,7 @@ func (s *WikiStartUp) RegisterHandlers(e *echo.Echo) {
 		UserService:  s.userService,
 		WebAuthn:     webAuthn,
 		KeyStore:     s.keyStore,
+		TokenIssuer:  tokenIssuer,
 		SessionStore: handlers.NewSessionStore(),
 		RateLimiter:  s.loginRateLimiter,
 	}
@@ -196,33 +269,70 @@ func (s *WikiStartUp) RegisterHandlers(e *echo.Echo) {
 		ImageResizer: s.imageResizer,
 	}
 	s.fileHandler = &handlers.FileHandler{FileManager: s.fileManager}
-	s.usersHandler = &handlers.UsersHandler{UserService: s.userService}
+	s.usersHandler = &handlers.UsersHandler{
+		UserService:    s.userService,
+		GroupService:   s.groupService,
+		SessionService: s.sessionService,
+	}
+	s.sessionsHandler = &handlers.SessionsHandler{
+		SessionService: s.sessionService,
+		UserService:    s.userService,
+	}
+	s.groupsHandler = &handlers.GroupsHandler{GroupService: s.groupService}
+	s.apiTokensHandler = &handlers.ApiTokensHandler{ApiTokenService: s.apiTokenService}
 	s.settingHandler = &handlers.SettingHandler{SettingService: s.settingService}
+	s.recentChangesHandler = &handlers.RecentChangesHandler{
+		RecentChangeService: s.recentChangeService,
+		SettingService:      s.settingService,
+		AccessService:       s.pageAccessService,
+	}
+	s.redirectHandler = &handlers.RedirectHandler{RedirectService: s.redirectService}
 
 	e.Validator = &handlers.CustomValidator{Validator: s.validator}
 
-	s.jwt = &middlewares.JWT{KeyStore: s.keyStore}
+	s.jwt = &middlewares.JWT{
+		KeyStore:        s.keyStore,
+		ApiTokenService: s.apiTokenService,
+		GroupService:    s.groupService,
+		SessionService:  s.sessionService,
+	}
 	e.Use(s.jwt.AuthMiddleware())
 
-	e.GET("/p/:id", s.pageHandler.Page)
+	e.GET("/p/*", s.pageHandler.Page)
 	api := e.Group(s.BaseRoute)
 	content := api.Group("")
+	content.Use(middlewares.TokenExpiryMiddleware())
 	if setting, ok := s.SettingCache.Get(); ok && setting != nil && setting.IsSiteProtected {
 		content.Use(middlewares.AuthorizeMiddleware())
 	}
 	content.GET("/page/:id", s.pageHandler.GetPageByID)
-	content.GET("/page/url/:url", s.pageHandler.GetPageByUrl)
+	content.GET("/page/url/*", s.pageHandler.GetPageByUrl)
+	content.GET("/page/:id/backlinks", s.pageHandler.GetBacklinks)
+	content.GET("/page/:id/links", s.pageHandler.GetLinks)
 	content.GET("/pages/list", s.pageHandler.GetPagesByParentID)
 	content.GET("/pages/list/:id", s.pageHandler.GetPagesByParentID)
 	content.GET("/pages/listall", s.pageHandler.GetAllPages)
 	content.GET("/pages/search", s.pageHandler.SearchPages)
+	content.GET("/pages/suggest", s.pageHandler.SuggestPages)
+	content.GET("/pages/recent", s.recentChangesHandler.GetRecentChanges)
+	content.GET("/pages/recent/atom", s.recentChangesHandler.GetAtomFeed)
+	content.GET("/pages/recent/rss", s.recentChangesHandler.GetRssFeed)
 
 	editor := api.Group("/editor")
-	editor.Use(middlewares.EditorMiddleware())
+	editor.Use(middlewares.TokenExpiryMiddleware(), middlewares.EditorMiddleware())
 	editor.POST("/pages", s.pageHandler.CreatePage)
 	editor.PUT("/pages/:id", s.pageHandler.UpdatePage)
 	editor.DELETE("/pages/:id", s.pageHandler.DeletePage)
+	editor.POST("/pages/:id/move", s.pageHandler.MovePage)
 	editor.GET("/pagerevision/:id", s.pageHandler.GetLatestRevision)
+	editor.GET("/pages/:id/revisions", s.pageHandler.ListRevisions)
+	editor.GET("/pages/:id/revisions/:revisionId", s.pageHandler.GetRevision)
+	editor.POST("/pages/:id/restore/:revisionId", s.pageHandler.RestoreRevision)
+	editor.GET("/pages/:id/diff", s.pageHandler.GetPageDiff)
+	editor.GET("/revisions/author/:author", s.pageHandler.ListRevisionsByAuthor)
+	editor.GET("/pages/:id/acl", s.pageHandler.GetPageAcl)
+	editor.PUT("/pages/:id/acl", s.pageHandler.UpdatePageAcl)
+	editor.DELETE("/pages/:id/acl", s.pageHandler.DeletePageAcl)
 	editor.POST("/upload", s.uploadHandler.UploadFile)
 	editor.POST("/ckeditor/upload", s.uploadHandler.CKEditorUpload)
 	editor.POST("/ckeditor/upload/regeneratethumbnail", s.uploadHandler.ResizeAllImages)
@@ -234,12 +344,36 @@ func (s *WikiStartUp) RegisterHandlers(e *echo.Echo) {
 	editor.GET("/files/info", s.fileHandler.GetFileInfo)
 
 	admin := api.Group("/admin")
-	admin.Use(middlewares.AdminMiddleware())
+	admin.Use(middlewares.TokenExpiryMiddleware(), middlewares.AdminMiddleware())
 	admin.GET("/users", s.usersHandler.GetUsers)
 	admin.GET("/users/:id", s.usersHandler.GetUser)
 	admin.POST("/users", s.usersHandler.CreateUser)
 	admin.PUT("/users/:id", s.usersHandler.UpdateUser)
+	admin.POST("/users/:id/logout", s.sessionsHandler.LogoutUser)
+	admin.GET("/users/:username/groups", s.groupsHandler.GetUserGroups)
+	admin.GET("/groups", s.groupsHandler.GetGroups)
+	admin.GET("/groups/:id", s.groupsHandler.GetGroup)
+	admin.POST("/groups", s.groupsHandler.CreateGroup)
+	admin.PUT("/groups/:id", s.groupsHandler.UpdateGroup)
+	admin.DELETE("/groups/:id", s.groupsHandler.DeleteGroup)
+	admin.POST("/groups/:id/members", s.groupsHandler.AddMember)
+	admin.DELETE("/groups/:id/members/:username", s.groupsHandler.RemoveMember)
 	admin.POST("/pages/rebuildsearch", s.pageHandler.RebuildSearchIndex)
+	admin.GET("/jobs/searchindex", s.pageHandler.GetSearchIndexJob)
+	admin.POST("/pages/rebuildlinks", s.pageHandler.RebuildLinkIndex)
+	admin.GET("/pages/linkreport", s.pageHandler.GetLinkReport)
+	admin.GET("/pages/trash", s.pageHandler.GetDeletedPages)
+	admin.POST("/pages/trash/:id/restore", s.pageHandler.RestoreDeletedPage)
+	admin.DELETE("/pages/trash/:id", s.pageHandler.PurgePage)
+	admin.GET("/pages/searchindex", s.pageHandler.CheckSearchIndex)
+	admin.POST("/pages/searchindex/repair", s.pageHandler.RepairSearchIndex)
+	admin.GET("/pages/orphans", s.pageHandler.GetOrphanPages)
+	admin.POST("/pages/orphans/repair", s.pageHandler.RepairOrphanPages)
+	admin.GET("/redirects", s.redirectHandler.GetRedirects)
+	admin.GET("/redirects/:id", s.redirectHandler.GetRedirect)
+	admin.POST("/redirects", s.redirectHandler.CreateRedirect)
+	admin.PUT("/redirects/:id", s.redirectHandler.UpdateRedirect)
+	admin.DELETE("/redirects/:id", s.redirectHandler.DeleteRedirect)
 
 	api.GET("/setting", s.settingHandler.GetSetting)
 	api.GET("/securitysetting", s.settingHandler.GetSecuritySetting)
@@ -247,10 +381,15 @@ func (s *WikiStartUp) RegisterHandlers(e *echo.Echo) {
 	admin.POST("/securitysetting", s.settingHandler.UpdateSecuritySetting)
 
 	users := api.Group("/user")
-	users.Use(middlewares.AuthorizeMiddleware())
+	users.Use(middlewares.TokenExpiryMiddleware(), middlewares.AuthorizeMiddleware(), middlewares.NoApiTokenMiddleware())
 	users.GET("/me", s.usersHandler.GetCurrentUser)
 	users.GET("/role", s.authHandler.GetRole)
 	users.POST("/changepassword", s.authHandler.ChangePassword)
+	users.GET("/tokens", s.apiTokensHandler.GetTokens)
+	users.POST("/tokens", s.apiTokensHandler.CreateToken)
+	users.DELETE("/tokens/:id", s.apiTokensHandler.RevokeToken)
+	users.GET("/sessions", s.sessionsHandler.GetSessions)
+	users.DELETE("/sessions/:id", s.sessionsHandler.RevokeSession)
 
 	// FIDO2/WebAuthn routes for authenticated users
 	users.POST("/passkey/begin-register", s.fido2Handler.BeginRegistration)
@@ -261,12 +400,66 @@ func (s *WikiStartUp) RegisterHandlers(e *echo.Echo) {
 	api.POST("/auth/login", s.authHandler.Login)
 	api.GET("/auth/publickey/:id", s.authHandler.GetPublicKey)
 	api.POST("/auth/logout", s.authHandler.Logout)
+	api.POST("/auth/refresh", s.authHandler.Refresh)
 
 	// FIDO2/WebAuthn public routes
 	api.POST("/auth/passkey/begin-login", s.fido2Handler.BeginLogin)
 	api.POST("/auth/passkey/finish-login", s.fido2Handler.FinishLogin)
 }
 
+// purgeExpiredPages periodically empties the recycle bin based on the retention setting.
+func (s *WikiStartUp) purgeExpiredPages() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		siteSetting, ok := s.SettingCache.Get()
+		if ok && siteSetting != nil && siteSetting.TrashRetentionDays > 0 {
+			count, err := s.pageService.PurgeExpiredPages(time.Duration(siteSetting.TrashRetentionDays) * 24 * time.Hour)
+			logIfError(err)
+			if count > 0 {
+				log.Printf("Purged %d pages from the recycle bin\n", count)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// repairSearchIndex fixes the search index at startup when an update was interrupted.
+// A rebuild replaces the whole index, so there is nothing to repair while it runs.
+func (s *WikiStartUp) repairSearchIndex() {
+	if s.searchService.GetSearchIndexJob().Status == pages.SearchIndexJobRunning {
+		return
+	}
+	report, err := s.searchService.CheckSearchIndex(true)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if report.Repaired {
+		log.Printf("Repaired search index: %d unindexed, %d outdated and %d deleted pages, %d missing and %d stale postings\n",
+			len(report.UnindexedPages), len(report.OutdatedPages), len(report.DeletedPages), len(report.MissingPostings), len(report.StalePostings))
+	}
+}
+
+// onSettingUpdated switches the search tokenizer when the site language changes, or
+// attachment indexing when it is turned on or off, and rebuilds the search index in
+// the background.
+func (s *WikiStartUp) onSettingUpdated(previous *setting.Setting, updated *setting.Setting) {
+	languageChanged := previous == nil || previous.Language != updated.Language
+	attachmentsChanged := previous == nil || previous.IndexAttachments != updated.IndexAttachments
+	if !languageChanged && !attachmentsChanged {
+		return
+	}
+	s.searchService.SetLanguage(updated.Language)
+	s.searchService.SetIndexAttachments(updated.IndexAttachments)
+	if attachmentsChanged {
+		_, err := s.searchService.StartRebuildSearchIndex()
+		logIfError(err)
+	} else {
+		logIfError(s.searchService.RebuildSearchIndexIfOutdated())
+	}
+}
+
 func logIfError(err error) {
 	if err != nil {
 		log.Println(err)
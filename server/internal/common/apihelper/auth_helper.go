@@ -62,6 +62,48 @@ func GetUserIdAndRoleFromToken(token *jwt.Token) (string, string) {
 	return str(uid), str(role)
 }
 
+// GetSessionID returns the session of the auth token, or "" for API tokens.
+func GetSessionID(e echo.Context) string {
+	token, ok := e.Get("token").(*jwt.Token)
+	if !ok || token == nil {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims == nil {
+		return ""
+	}
+	return str(claims["jti"])
+}
+
+// GetUserGroups returns the groups of the user, as recorded in the token at login.
+func GetUserGroups(e echo.Context) []string {
+	token, ok := e.Get("token").(*jwt.Token)
+	if !ok || token == nil {
+		return nil
+	}
+	return GetUserGroupsFromToken(token)
+}
+
+func GetUserGroupsFromToken(token *jwt.Token) []string {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims == nil {
+		return nil
+	}
+	switch list := claims["groups"].(type) {
+	case []string:
+		return list
+	case []interface{}:
+		groups := make([]string, 0, len(list))
+		for _, group := range list {
+			if s := str(group); s != "" {
+				groups = append(groups, s)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
 func str(o interface{}) string {
 	s, ok := o.(string)
 	if !ok {
@@ -86,4 +128,12 @@ func RemoveAuthCookie(e echo.Context) {
 		HttpOnly: true,
 		Path:     "/",
 	})
+	e.SetCookie(&http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Expires:  time.Now(),
+		SameSite: http.SameSiteStrictMode,
+		HttpOnly: true,
+		Path:     "/",
+	})
 }
@@ -29,6 +29,8 @@ const (
 	ErrCodeUnauthorized   ErrorCode = "UNAUTHORIZED"
 	ErrCodeRateLimit      ErrorCode = "RATE_LIMIT"
 	ErrCodeForbidden      ErrorCode = "FORBIDDEN"
+	// ErrCodeTokenExpired tells the client to refresh the access token and retry
+	ErrCodeTokenExpired ErrorCode = "TOKEN_EXPIRED"
 )
 
 func GetErrorCode(status int) ErrorCode {
@@ -68,6 +70,10 @@ func NewUnauthorizedError(message string) ErrorResponse {
 	return ErrorResponse{Message: message, Code: ErrCodeUnauthorized}
 }
 
+func NewTokenExpiredError(message string) ErrorResponse {
+	return ErrorResponse{Message: message, Code: ErrCodeTokenExpired}
+}
+
 func NewForbiddenError(message string) ErrorResponse {
 	return ErrorResponse{Message: message, Code: ErrCodeForbidden}
 }
@@ -0,0 +1,132 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time
+	Items       []*Item
+}
+
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Author      string
+	Published   time.Time
+}
+
+// ToAtom renders the feed as an Atom 1.0 document.
+func (f *Feed) ToAtom() ([]byte, error) {
+	doc := &atomFeed{
+		Xmlns:    "http://www.w3.org/2005/Atom",
+		ID:       f.Link,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Link:     []*atomLink{{Href: f.Link, Rel: "alternate"}},
+	}
+	for _, item := range f.Items {
+		doc.Entries = append(doc.Entries, &atomEntry{
+			ID:      item.ID,
+			Title:   item.Title,
+			Link:    &atomLink{Href: item.Link, Rel: "alternate"},
+			Summary: item.Description,
+			Author:  &atomAuthor{Name: item.Author},
+			Updated: item.Published.UTC().Format(time.RFC3339),
+		})
+	}
+	return marshal(doc)
+}
+
+// ToRss renders the feed as an RSS 2.0 document.
+func (f *Feed) ToRss() ([]byte, error) {
+	channel := &rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+	}
+	for _, item := range f.Items {
+		channel.Items = append(channel.Items, &rssItem{
+			Guid:        &rssGuid{Value: item.ID, IsPermaLink: false},
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			Author:      item.Author,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return marshal(&rssDocument{Version: "2.0", Channel: channel})
+}
+
+func marshal(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"feed"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Updated  string       `xml:"updated"`
+	Link     []*atomLink  `xml:"link"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Link    *atomLink   `xml:"link"`
+	Summary string      `xml:"summary,omitempty"`
+	Author  *atomAuthor `xml:"author"`
+	Updated string      `xml:"updated"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type rssDocument struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Channel *rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Guid        *rssGuid `xml:"guid"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGuid struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
@@ -14,6 +14,7 @@ type FileManager interface {
 	DeleteFile(fileName string, path string) error
 	CreatePath(path string) error
 	ListFiles(path string) ([]string, error)
+	FileExists(fileName string, path string) (bool, error)
 	DeletePathIfEmpty(path string) error
 }
 
@@ -130,6 +131,21 @@ func (fm *fileManager) ListFiles(path string) ([]string, error) {
 	return fileNames, nil
 }
 
+func (fm *fileManager) FileExists(fileName string, path string) (bool, error) {
+	if !fm.isPathAllowed(path) || strings.Contains(fileName, "..") {
+		return false, fmt.Errorf("invalid path")
+	}
+	filePath := filepath.FromSlash(filepath.Join(fm.RootPath, path, fileName))
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
 func (fm *fileManager) isFileNameAllowed(fileName string) bool {
 	ext := strings.ToLower(filepath.Ext(fileName))
 	for _, disallowedExt := range fm.DisallowedExtensions {
@@ -0,0 +1,307 @@
+package pages
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxAttachmentTextLength limits the text indexed from one attachment, in bytes.
+const maxAttachmentTextLength = 200 * 1024
+
+var attachmentExtensions = map[string]bool{
+	".txt":      true,
+	".md":       true,
+	".markdown": true,
+	".pdf":      true,
+}
+
+// IsIndexableAttachment reports whether the text of an uploaded file can be indexed.
+func IsIndexableAttachment(fileName string) bool {
+	return attachmentExtensions[strings.ToLower(path.Ext(fileName))]
+}
+
+// ExtractAttachmentText returns the plain text of a TXT, Markdown or PDF file. It reports
+// false when the file type is not supported or no text could be read.
+func ExtractAttachmentText(fileName string, data []byte) (string, bool) {
+	var text string
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".txt", ".md", ".markdown":
+		if !utf8.Valid(data) {
+			return "", false
+		}
+		text = string(data)
+	case ".pdf":
+		text = extractPdfText(data)
+	default:
+		return "", false
+	}
+	if len(text) > maxAttachmentTextLength {
+		text = strings.ToValidUTF8(text[:maxAttachmentTextLength], "")
+	}
+	return text, strings.TrimSpace(text) != ""
+}
+
+var pdfStreamRegexp = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+
+// extractPdfText reads the text drawn by the content streams of a PDF. Uncompressed and
+// Flate compressed streams are supported. Fonts with custom encodings produce no
+// readable text, so this covers documents exported by common editors, not every PDF.
+func extractPdfText(data []byte) string {
+	var text strings.Builder
+	for _, loc := range pdfStreamRegexp.FindAllSubmatchIndex(data, -1) {
+		dict := string(data[loc[2]:loc[3]])
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		// Fonts, images, metadata and object streams are not page content
+		if strings.Contains(dict, "/Subtype") || strings.Contains(dict, "/Length1") ||
+			strings.Contains(dict, "/ObjStm") || strings.Contains(dict, "/XRef") {
+			continue
+		}
+		stream := data[start : start+end]
+		if strings.Contains(dict, "/Filter") {
+			if !strings.Contains(dict, "/FlateDecode") {
+				continue
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			// Streams may have trailing bytes after the compressed data
+			stream, _ = io.ReadAll(io.LimitReader(reader, maxAttachmentTextLength*4))
+			reader.Close()
+		}
+		writePdfContentText(&text, stream)
+		if text.Len() > maxAttachmentTextLength {
+			break
+		}
+	}
+	return text.String()
+}
+
+// writePdfContentText writes the strings shown by the text operators of a content stream.
+func writePdfContentText(text *strings.Builder, content []byte) {
+	lexer := &pdfLexer{data: content}
+	inText := false
+	var operands []pdfToken
+	for {
+		token, ok := lexer.next()
+		if !ok {
+			return
+		}
+		if token.kind != pdfOperator {
+			operands = append(operands, token)
+			continue
+		}
+		switch token.value {
+		case "BT":
+			inText = true
+		case "ET":
+			inText = false
+			text.WriteString("\n")
+		case "Tj", "'", "\"":
+			if inText {
+				if token.value != "Tj" {
+					text.WriteString("\n")
+				}
+				for _, operand := range operands {
+					if operand.kind == pdfString {
+						text.WriteString(operand.value)
+					}
+				}
+			}
+		case "TJ":
+			if inText {
+				for _, operand := range operands {
+					switch operand.kind {
+					case pdfString:
+						text.WriteString(operand.value)
+					case pdfNumber:
+						// A large negative adjustment moves the next glyph by a word space
+						if n, err := strconv.ParseFloat(operand.value, 64); err == nil && n < -200 {
+							text.WriteString(" ")
+						}
+					}
+				}
+			}
+		case "Td", "TD", "T*", "Tm":
+			if inText {
+				text.WriteString(" ")
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+type pdfTokenKind int
+
+const (
+	pdfOperator pdfTokenKind = iota
+	pdfString
+	pdfNumber
+	pdfOther
+)
+
+type pdfToken struct {
+	kind  pdfTokenKind
+	value string
+}
+
+// pdfLexer splits a content stream into operators and operands. Arrays are flattened,
+// so the strings and numbers of a TJ array are the operands of TJ.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case isPdfWhitespace(c) || c == '[' || c == ']':
+			l.pos++
+		case c == '(':
+			return pdfToken{pdfString, l.readLiteralString()}, true
+		case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+			l.pos += 2
+			return pdfToken{pdfOther, "<<"}, true
+		case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+			l.pos += 2
+			return pdfToken{pdfOther, ">>"}, true
+		case c == '<':
+			return pdfToken{pdfString, l.readHexString()}, true
+		case c == '/':
+			start := l.pos
+			l.pos++
+			l.skipRegular()
+			return pdfToken{pdfOther, string(l.data[start:l.pos])}, true
+		case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := l.pos
+			l.skipRegular()
+			return pdfToken{pdfNumber, string(l.data[start:l.pos])}, true
+		default:
+			start := l.pos
+			l.skipRegular()
+			if l.pos == start {
+				l.pos++
+				continue
+			}
+			return pdfToken{pdfOperator, string(l.data[start:l.pos])}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+func (l *pdfLexer) skipRegular() {
+	for l.pos < len(l.data) && !isPdfWhitespace(l.data[l.pos]) && !isPdfDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+}
+
+func (l *pdfLexer) readLiteralString() string {
+	var value []byte
+	depth := 0
+	for l.pos++; l.pos < len(l.data); l.pos++ {
+		c := l.data[l.pos]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				l.pos++
+				return decodePdfString(value)
+			}
+			depth--
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.data) {
+				return decodePdfString(value)
+			}
+			c = l.data[l.pos]
+			switch c {
+			case 'n', 'r':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'b', 'f':
+				c = ' '
+			case '\r', '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					n := 0
+					for i := 0; i < 3 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					l.pos--
+					c = byte(n)
+				}
+			}
+		}
+		value = append(value, c)
+	}
+	return decodePdfString(value)
+}
+
+func (l *pdfLexer) readHexString() string {
+	var value []byte
+	digits := make([]byte, 0, 2)
+	for l.pos++; l.pos < len(l.data) && l.data[l.pos] != '>'; l.pos++ {
+		if d, err := strconv.ParseUint(string(l.data[l.pos]), 16, 8); err == nil {
+			digits = append(digits, byte(d))
+			if len(digits) == 2 {
+				value = append(value, digits[0]<<4|digits[1])
+				digits = digits[:0]
+			}
+		}
+	}
+	if len(digits) == 1 {
+		value = append(value, digits[0]<<4)
+	}
+	l.pos++
+	return decodePdfString(value)
+}
+
+// decodePdfString reads UTF-16 strings with a byte order mark and treats other
+// strings as Latin-1, dropping control characters.
+func decodePdfString(value []byte) string {
+	var text strings.Builder
+	if len(value) >= 2 && value[0] == 0xfe && value[1] == 0xff {
+		for i := 2; i+1 < len(value); i += 2 {
+			r := rune(value[i])<<8 | rune(value[i+1])
+			if unicode.IsPrint(r) || unicode.IsSpace(r) {
+				text.WriteRune(r)
+			}
+		}
+		return text.String()
+	}
+	for _, b := range value {
+		r := rune(b)
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			text.WriteRune(r)
+		}
+	}
+	return text.String()
+}
+
+func isPdfWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPdfDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
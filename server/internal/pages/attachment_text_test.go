@@ -0,0 +1,40 @@
+package pages
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExtractAttachmentText(t *testing.T) {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write([]byte("BT /F1 12 Tf 72 700 Td [(Kuber)-10(netes)-250(cluster)] TJ T* <486F7374> Tj ET"))
+	w.Close()
+	pdf := fmt.Sprintf("%%PDF-1.4\n"+
+		"1 0 obj\n<< /Length 44 >>\nstream\nBT (Release \\(draft\\) notes) Tj ET\nendstream\nendobj\n"+
+		"2 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n"+
+		"3 0 obj\n<< /Length 20 /Subtype /Image >>\nstream\nBT (hidden) Tj ET\nendstream\nendobj\n",
+		compressed.Len(), compressed.String())
+
+	text, ok := ExtractAttachmentText("guide.PDF", []byte(pdf))
+	if !ok {
+		t.Fatal("expected text from the PDF")
+	}
+	terms := strings.Join(TokenizeAll(text), " ")
+	if terms != "release draft notes kubernetes cluster host" {
+		t.Errorf("unexpected PDF terms %q from text %q", terms, text)
+	}
+
+	if text, ok := ExtractAttachmentText("notes.md", []byte("# Setup\n\nRun `make`")); !ok || !strings.Contains(text, "Setup") {
+		t.Errorf("unexpected Markdown text %q", text)
+	}
+	if _, ok := ExtractAttachmentText("photo.png", []byte("not text")); ok {
+		t.Error("images should not be indexed")
+	}
+	if _, ok := ExtractAttachmentText("broken.txt", []byte{0xff, 0xfe, 0x00}); ok {
+		t.Error("invalid UTF-8 text should not be indexed")
+	}
+}
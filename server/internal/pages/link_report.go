@@ -0,0 +1,164 @@
+package pages
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var mediaRefRegexp = regexp.MustCompile(`(?:src|href)="/media(/[^"#?]*)[^"]*"`)
+
+// BrokenReference is a link or media reference in a page that leads nowhere.
+type BrokenReference struct {
+	PageID    int    `json:"pageId"`
+	PageTitle string `json:"pageTitle"`
+	PageUrl   string `json:"pageUrl"`
+	Url       string `json:"url"`
+	Reason    string `json:"reason"`
+}
+
+type LinkReport struct {
+	BrokenLinks      []*BrokenReference `json:"brokenLinks"`
+	MissingMedia     []*BrokenReference `json:"missingMedia"`
+	UnreachablePages []*PageMeta        `json:"unreachablePages"`
+	PageCount        int                `json:"pageCount"`
+	GeneratedAt      time.Time          `json:"generatedAt"`
+}
+
+// GetLinkReport scans the content of every page for internal links to urls without a page
+// and media references to files that do not exist. It also lists the pages that cannot be
+// reached from the page tree and that no other page links to.
+func (s *LinkService) GetLinkReport() (*LinkReport, error) {
+	allPages, err := s.PageRepository.GetAllPages(true)
+	if err != nil {
+		return nil, err
+	}
+	report := &LinkReport{
+		BrokenLinks:      make([]*BrokenReference, 0),
+		MissingMedia:     make([]*BrokenReference, 0),
+		UnreachablePages: make([]*PageMeta, 0),
+		PageCount:        len(allPages),
+		GeneratedAt:      time.Now(),
+	}
+	sort.Slice(allPages, func(i, j int) bool {
+		return allPages[i].ID < allPages[j].ID
+	})
+	pageMap := make(map[int]*PageMeta, len(allPages))
+	urlMap := make(map[string]*PageMeta, len(allPages))
+	for _, page := range allPages {
+		pageMap[page.ID] = page
+		urlMap[page.Url] = page
+	}
+
+	linked := make(map[int]bool)
+	mediaExists := make(map[string]bool)
+	for _, meta := range allPages {
+		page, err := s.PageRepository.GetPageByID(meta.ID)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			continue
+		}
+		for _, link := range ExtractInternalLinks(page.Content) {
+			target, reason := s.resolveLinkTarget(link, urlMap)
+			if reason == "" {
+				if target != nil && target.ID != page.ID {
+					linked[target.ID] = true
+				}
+				continue
+			}
+			report.BrokenLinks = append(report.BrokenLinks, newBrokenReference(page, link, reason))
+		}
+		for _, ref := range extractMediaRefs(page.Content) {
+			exists, checked := mediaExists[ref]
+			if !checked {
+				dir, file := path.Split(ref)
+				exists, err = s.FileManager.FileExists(file, dir)
+				if err != nil {
+					exists = false
+				}
+				mediaExists[ref] = exists
+			}
+			if !exists {
+				report.MissingMedia = append(report.MissingMedia, newBrokenReference(page, "/media"+ref, "file not found"))
+			}
+		}
+	}
+
+	for _, page := range allPages {
+		if !linked[page.ID] && !isReachableFromRoot(page, pageMap) {
+			report.UnreachablePages = append(report.UnreachablePages, page)
+		}
+	}
+	return report, nil
+}
+
+// resolveLinkTarget finds the page a link leads to, following redirects. It returns the
+// reason when the link is broken. Redirects to external sites return no page and no reason.
+func (s *LinkService) resolveLinkTarget(link string, urlMap map[string]*PageMeta) (*PageMeta, string) {
+	if page, ok := urlMap[link]; ok {
+		return page, ""
+	}
+	target, err := s.RedirectService.ResolveUrl(link)
+	if err != nil {
+		return nil, "redirect cannot be resolved: " + err.Error()
+	}
+	if target != "" {
+		if IsExternalUrl(target) {
+			return nil, ""
+		}
+		if page, ok := urlMap[target]; ok {
+			return page, ""
+		}
+		link = target
+	}
+	if page, err := s.PageRepository.GetPageByUrl(link); err == nil && page != nil && page.IsDeleted() {
+		return nil, "page is in the recycle bin: " + link
+	}
+	return nil, "page not found: " + link
+}
+
+// isReachableFromRoot checks whether walking up the parents of a page ends at a top level
+// page, so the page shows up in the tree navigation.
+func isReachableFromRoot(page *PageMeta, pageMap map[int]*PageMeta) bool {
+	visited := map[int]bool{page.ID: true}
+	current := page
+	for current.ParentID != nil {
+		parent, ok := pageMap[*current.ParentID]
+		if !ok || visited[parent.ID] {
+			return false
+		}
+		visited[parent.ID] = true
+		current = parent
+	}
+	return true
+}
+
+func extractMediaRefs(content string) []string {
+	seen := make(map[string]bool)
+	refs := make([]string, 0)
+	for _, match := range mediaRefRegexp.FindAllStringSubmatch(content, -1) {
+		ref := match[1]
+		if unescaped, err := url.PathUnescape(ref); err == nil {
+			ref = unescaped
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func newBrokenReference(page *Page, url string, reason string) *BrokenReference {
+	return &BrokenReference{
+		PageID:    page.ID,
+		PageTitle: page.Title,
+		PageUrl:   page.Url,
+		Url:       url,
+		Reason:    reason,
+	}
+}
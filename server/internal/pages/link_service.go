@@ -0,0 +1,195 @@
+package pages
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"wikigo/internal/common/errors"
+	"wikigo/internal/filemanager"
+)
+
+// PageLink is an internal link found in the content of a page.
+// Page is nil when no visible page exists at the linked url.
+type PageLink struct {
+	Url  string    `json:"url"`
+	Page *PageMeta `json:"page,omitempty"`
+}
+
+type LinkService struct {
+	PageLinkListRepository PageLinkListRepository
+	PageRepository         PageRepository
+	RedirectService        *RedirectService
+	FileManager            filemanager.FileManager
+}
+
+func (s *LinkService) Init() error {
+	return s.PageLinkListRepository.Init()
+}
+
+func (s *LinkService) AddPageLinks(page *Page) error {
+	if page == nil {
+		return nil
+	}
+	return s.PageLinkListRepository.UpdatePageLinkLists(ExtractInternalLinks(page.Content), []string{}, page.ID)
+}
+
+func (s *LinkService) UpdatePageLinks(page *Page, oldPage *Page) error {
+	if page == nil || page.ID <= 0 {
+		return nil
+	}
+	oldLinks := []string{}
+	if oldPage != nil {
+		oldLinks = ExtractInternalLinks(oldPage.Content)
+	}
+	return s.PageLinkListRepository.UpdatePageLinkLists(ExtractInternalLinks(page.Content), oldLinks, page.ID)
+}
+
+func (s *LinkService) DeletePageLinks(page *Page) error {
+	return s.PageLinkListRepository.UpdatePageLinkLists([]string{}, ExtractInternalLinks(page.Content), page.ID)
+}
+
+// GetBacklinks lists the pages that link to a page, either directly or through
+// a redirect that leads to it. Pages the viewer may not see are left out.
+func (s *LinkService) GetBacklinks(id int, access *PageAccess) ([]*PageMeta, error) {
+	page, err := s.PageRepository.GetPageByID(id)
+	if err != nil || page == nil || page.IsDeleted() {
+		return nil, errors.NotFound("page not found")
+	}
+	urls := []string{page.Url}
+	redirects, err := s.RedirectService.Repository.GetRedirectsByToUrl(page.Url)
+	if err != nil {
+		return nil, err
+	}
+	for _, redirect := range redirects {
+		urls = append(urls, redirect.FromUrl)
+	}
+
+	seen := map[int]bool{page.ID: true}
+	result := make([]*PageMeta, 0)
+	for _, u := range urls {
+		list, err := s.PageLinkListRepository.GetPageLinkList(u)
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			continue
+		}
+		for _, pageId := range list.PageIds {
+			if seen[pageId] {
+				continue
+			}
+			seen[pageId] = true
+			source, err := s.PageRepository.GetPageByID(pageId)
+			if err != nil {
+				return nil, err
+			}
+			if source == nil || source.IsDeleted() || !access.CanView(source.ID, source.ParentID, source.IsProtected) {
+				continue
+			}
+			result = append(result, toPageMeta(source))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Title < result[j].Title
+	})
+	return result, nil
+}
+
+// GetLinkingPageIds returns the IDs of the pages whose content links to one of the urls.
+func (s *LinkService) GetLinkingPageIds(urls []string) ([]int, error) {
+	seen := make(map[int]bool)
+	result := make([]int, 0)
+	for _, u := range urls {
+		list, err := s.PageLinkListRepository.GetPageLinkList(u)
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			continue
+		}
+		for _, pageId := range list.PageIds {
+			if !seen[pageId] {
+				seen[pageId] = true
+				result = append(result, pageId)
+			}
+		}
+	}
+	sort.Ints(result)
+	return result, nil
+}
+
+// GetLinks lists the internal links in the content of a page, in the order they appear.
+// Links to pages the viewer may not see are listed without the page.
+func (s *LinkService) GetLinks(id int, access *PageAccess) ([]*PageLink, error) {
+	page, err := s.PageRepository.GetPageByID(id)
+	if err != nil || page == nil || page.IsDeleted() {
+		return nil, errors.NotFound("page not found")
+	}
+	links := ExtractInternalLinks(page.Content)
+	result := make([]*PageLink, len(links))
+	for i, link := range links {
+		result[i] = &PageLink{Url: link}
+		target, err := s.PageRepository.GetPageByUrl(link)
+		if err != nil {
+			return nil, err
+		}
+		if target != nil && !target.IsDeleted() && access.CanView(target.ID, target.ParentID, target.IsProtected) {
+			result[i].Page = toPageMeta(target)
+		}
+	}
+	return result, nil
+}
+
+func (s *LinkService) RebuildLinkIndex() error {
+	pages, err := s.PageRepository.GetAllPages(true)
+	if err != nil {
+		return err
+	}
+	if err := s.PageLinkListRepository.DeleteAll(); err != nil {
+		return err
+	}
+	for _, page := range pages {
+		pageWithContent, err := s.PageRepository.GetPageByID(page.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.AddPageLinks(pageWithContent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractInternalLinks returns the distinct page urls linked from html content
+// through /p/... hrefs. Anchors and query strings are ignored.
+func ExtractInternalLinks(content string) []string {
+	seen := make(map[string]bool)
+	links := make([]string, 0)
+	for _, match := range internalLinkRegexp.FindAllStringSubmatch(content, -1) {
+		link := match[1]
+		if unescaped, err := url.PathUnescape(link); err == nil {
+			link = unescaped
+		}
+		if len(link) > 1 {
+			link = strings.TrimSuffix(link, "/")
+		}
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+func toPageMeta(page *Page) *PageMeta {
+	return &PageMeta{
+		ID:               page.ID,
+		ParentID:         page.ParentID,
+		Url:              page.Url,
+		Title:            page.Title,
+		IsPinned:         page.IsPinned,
+		IsProtected:      page.IsProtected,
+		SortChildrenDesc: page.SortChildrenDesc,
+	}
+}
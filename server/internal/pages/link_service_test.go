@@ -0,0 +1,73 @@
+package pages
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractInternalLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "No links",
+			input:    `<p>Plain text</p>`,
+			expected: []string{},
+		},
+		{
+			name:     "Internal links in order",
+			input:    `<a href="/p/guides">Guides</a> and <a href="/p/guides/setup#install">Setup</a>`,
+			expected: []string{"/guides", "/guides/setup"},
+		},
+		{
+			name:     "Duplicates, trailing slash and query string",
+			input:    `<a href="/p/faq/">FAQ</a><a href="/p/faq?x=1">FAQ</a>`,
+			expected: []string{"/faq"},
+		},
+		{
+			name:     "Home page",
+			input:    `<a href="/p/">Home</a>`,
+			expected: []string{"/"},
+		},
+		{
+			name:     "External and media links are ignored",
+			input:    `<a href="https://example.com/p/x">Ext</a><img src="/media/a.png"><a href="/pages">Pages</a>`,
+			expected: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractInternalLinks(tt.input)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ExtractInternalLinks() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsReachableFromRoot(t *testing.T) {
+	one, two, three, missing := 1, 2, 3, 99
+	pageMap := map[int]*PageMeta{
+		1: {ID: 1},
+		2: {ID: 2, ParentID: &one},
+		3: {ID: 3, ParentID: &missing},
+		4: {ID: 4, ParentID: &three},
+		5: {ID: 5, ParentID: &two},
+	}
+	tests := []struct {
+		id       int
+		expected bool
+	}{
+		{1, true},
+		{5, true},
+		{3, false},
+		{4, false},
+	}
+	for _, tt := range tests {
+		if got := isReachableFromRoot(pageMap[tt.id], pageMap); got != tt.expected {
+			t.Errorf("isReachableFromRoot(%d) = %v, want %v", tt.id, got, tt.expected)
+		}
+	}
+}
@@ -8,21 +8,23 @@ import (
 )
 
 type Page struct {
-	ID               int       `json:"id"`
-	ParentID         *int      `json:"parentId"`
-	Url              string    `json:"url" validate:"required,max=100"`
-	Title            string    `json:"title" validate:"required,max=100"`
-	ShortDesc        string    `json:"shortDesc" validate:"max=300"`
-	Content          string    `json:"content"`
-	Tags             []string  `json:"tags"`
-	CreatedAt        time.Time `json:"createdAt"`
-	CreatedBy        string    `json:"createdBy"`
-	LastModifiedAt   time.Time `json:"lastModifiedAt"`
-	LastModifiedBy   string    `json:"lastModifiedBy"`
-	IsProtected      bool      `json:"isProtected"`
-	IsPinned         bool      `json:"isPinned"`
-	IsCategoryPage   bool      `json:"isCategoryPage"`
-	SortChildrenDesc bool      `json:"sortChildrenDesc"`
+	ID               int        `json:"id"`
+	ParentID         *int       `json:"parentId"`
+	Url              string     `json:"url" validate:"required,max=100"`
+	Title            string     `json:"title" validate:"required,max=100"`
+	ShortDesc        string     `json:"shortDesc" validate:"max=300"`
+	Content          string     `json:"content"`
+	Tags             []string   `json:"tags"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	CreatedBy        string     `json:"createdBy"`
+	LastModifiedAt   time.Time  `json:"lastModifiedAt"`
+	LastModifiedBy   string     `json:"lastModifiedBy"`
+	IsProtected      bool       `json:"isProtected"`
+	IsPinned         bool       `json:"isPinned"`
+	IsCategoryPage   bool       `json:"isCategoryPage"`
+	SortChildrenDesc bool       `json:"sortChildrenDesc"`
+	DeletedAt        *time.Time `json:"deletedAt,omitempty"`
+	DeletedBy        string     `json:"deletedBy,omitempty"`
 }
 
 func (p *Page) GetValue(field string) string {
@@ -47,6 +49,10 @@ func (p *Page) GetValue(field string) string {
 		return strconv.FormatBool(p.IsCategoryPage)
 	case "SortChildrenDesc":
 		return strconv.FormatBool(p.SortChildrenDesc)
+	case "DeletedAt":
+		if p.DeletedAt != nil {
+			return p.DeletedAt.Format(time.RFC3339Nano)
+		}
 	}
 	return ""
 }
@@ -72,6 +78,11 @@ func (p *Page) SetID(id int) {
 	p.ID = id
 }
 
+// IsDeleted reports whether the page is in the recycle bin.
+func (p *Page) IsDeleted() bool {
+	return p.DeletedAt != nil
+}
+
 func (p *Page) ContentHtml() template.HTML {
 	return template.HTML(p.Content)
 }
@@ -0,0 +1,323 @@
+package pages
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"wikigo/internal/common/errors"
+	"wikigo/internal/roles"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// PageViewer is the user pages are shown to. Anonymous visitors have no UserID.
+type PageViewer struct {
+	UserID string
+	Role   roles.Role
+	Groups []string
+}
+
+// rolePermission is the permission of a viewer on pages without an ACL.
+func (v *PageViewer) rolePermission() PagePermission {
+	switch {
+	case v.Role == roles.Admin:
+		return PermissionAdmin
+	case v.Role == roles.Editor:
+		return PermissionEdit
+	default:
+		return PermissionView
+	}
+}
+
+func (v *PageViewer) matches(entry *PageAclEntry) bool {
+	if v.UserID == "" {
+		return false
+	}
+	switch entry.PrincipalType {
+	case PrincipalUser:
+		return strings.EqualFold(entry.Principal, v.UserID)
+	case PrincipalGroup:
+		for _, group := range v.Groups {
+			if strings.EqualFold(entry.Principal, group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PageAclInfo describes the ACL of a page. Entries are set on the page itself, Effective
+// are the entries that apply, which are inherited from InheritedFrom when it is set.
+type PageAclInfo struct {
+	PageID        int             `json:"pageId"`
+	Entries       []*PageAclEntry `json:"entries"`
+	InheritedFrom *int            `json:"inheritedFrom"`
+	Effective     []*PageAclEntry `json:"effective"`
+}
+
+type PageAccessService struct {
+	Repository     PageAclRepository
+	PageRepository PageRepository
+
+	acls   map[int]*PageAcl
+	aclsMu sync.Mutex
+}
+
+func (s *PageAccessService) Init() error {
+	return s.Repository.Init()
+}
+
+// NewPageAccess returns the access checker for a viewer. It reads the ACLs and the page
+// tree once, so it is meant to be used for a single request.
+func (s *PageAccessService) NewPageAccess(viewer *PageViewer) (*PageAccess, error) {
+	acls, err := s.getAcls()
+	if err != nil {
+		return nil, err
+	}
+	access := &PageAccess{viewer: viewer, acls: acls, permissions: make(map[int]PagePermission)}
+	if len(acls) > 0 && viewer.Role != roles.Admin {
+		allPages, err := s.PageRepository.GetAllPages(true)
+		if err != nil {
+			return nil, err
+		}
+		access.pages = make(map[int]*PageMeta, len(allPages))
+		for _, page := range allPages {
+			access.pages[page.ID] = page
+		}
+	}
+	return access, nil
+}
+
+func (s *PageAccessService) GetPageAcl(pageId int) (*PageAclInfo, error) {
+	page, err := s.PageRepository.GetPageByID(pageId)
+	if err != nil || page == nil {
+		return nil, errors.NotFound("page not found")
+	}
+	acls, err := s.getAcls()
+	if err != nil {
+		return nil, err
+	}
+	info := &PageAclInfo{PageID: pageId, Entries: make([]*PageAclEntry, 0), Effective: make([]*PageAclEntry, 0)}
+	if acl := acls[pageId]; acl != nil {
+		info.Entries = acl.Entries
+	}
+	access := &PageAccess{acls: acls}
+	if source := access.findAcl(pageId, page.ParentID, s.getParentID); source != nil {
+		info.Effective = source.Entries
+		if source.PageID != pageId {
+			info.InheritedFrom = &source.PageID
+		}
+	}
+	return info, nil
+}
+
+// SetPageAcl replaces the ACL of a page. Only admins may set an ACL that does not grant
+// them admin permission, so other users cannot lock themselves out.
+func (s *PageAccessService) SetPageAcl(pageId int, entries []*PageAclEntry, viewer *PageViewer) error {
+	page, err := s.PageRepository.GetPageByID(pageId)
+	if err != nil || page == nil || page.IsDeleted() {
+		return errors.NotFound("page not found")
+	}
+	acl := &PageAcl{
+		PageID:    pageId,
+		Entries:   make([]*PageAclEntry, 0, len(entries)),
+		UpdatedAt: time.Now(),
+		UpdatedBy: viewer.UserID,
+	}
+	seen := make(map[string]int)
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		entry.Principal = strings.TrimSpace(entry.Principal)
+		key := string(entry.PrincipalType) + ":" + strings.ToLower(entry.Principal)
+		if i, ok := seen[key]; ok {
+			if entry.Permission.Includes(acl.Entries[i].Permission) {
+				acl.Entries[i].Permission = entry.Permission
+			}
+			continue
+		}
+		seen[key] = len(acl.Entries)
+		acl.Entries = append(acl.Entries, entry)
+	}
+	if err := validator.New().Struct(acl); err != nil {
+		return errors.NewValidationError("invalid ACL: "+err.Error(), "entries")
+	}
+	if viewer.Role != roles.Admin && !aclPermission(acl, viewer).Includes(PermissionAdmin) {
+		return errors.NewValidationError("the ACL must grant you admin permission", "entries")
+	}
+	defer s.invalidateAcls()
+	return s.Repository.SavePageAcl(acl)
+}
+
+// DeletePageAcl removes the ACL of a page, which then inherits the ACL of its ancestors.
+func (s *PageAccessService) DeletePageAcl(pageId int) error {
+	defer s.invalidateAcls()
+	return s.Repository.DeletePageAcl(pageId)
+}
+
+func (s *PageAccessService) getAcls() (map[int]*PageAcl, error) {
+	s.aclsMu.Lock()
+	defer s.aclsMu.Unlock()
+	if s.acls == nil {
+		list, err := s.Repository.ListPageAcls()
+		if err != nil {
+			return nil, err
+		}
+		acls := make(map[int]*PageAcl, len(list))
+		for _, acl := range list {
+			acls[acl.PageID] = acl
+		}
+		s.acls = acls
+	}
+	return s.acls, nil
+}
+
+func (s *PageAccessService) invalidateAcls() {
+	s.aclsMu.Lock()
+	s.acls = nil
+	s.aclsMu.Unlock()
+}
+
+func (s *PageAccessService) getParentID(pageId int) (*int, bool) {
+	page, err := s.PageRepository.GetPageByID(pageId)
+	if err != nil || page == nil {
+		return nil, false
+	}
+	return page.ParentID, true
+}
+
+// PageAccess decides what a viewer may do with pages. Global admins may do anything.
+// Other users get the highest permission granted to them or their groups by the ACL
+// closest to the page, and no access when it grants them nothing. Pages without an ACL
+// follow the global role. Protected pages stay hidden from anonymous visitors.
+type PageAccess struct {
+	viewer      *PageViewer
+	acls        map[int]*PageAcl
+	pages       map[int]*PageMeta
+	permissions map[int]PagePermission
+}
+
+// Permission returns the permission of the viewer on a page.
+func (a *PageAccess) Permission(pageId int, parentID *int) PagePermission {
+	if a.viewer.Role == roles.Admin {
+		return PermissionAdmin
+	}
+	if len(a.acls) == 0 {
+		return a.viewer.rolePermission()
+	}
+	if permission, ok := a.permissions[pageId]; ok {
+		return permission
+	}
+	permission := a.viewer.rolePermission()
+	if acl := a.findAcl(pageId, parentID, a.getParentID); acl != nil {
+		permission = aclPermission(acl, a.viewer)
+	}
+	a.permissions[pageId] = permission
+	return permission
+}
+
+func (a *PageAccess) CanView(pageId int, parentID *int, isProtected bool) bool {
+	if isProtected && a.viewer.UserID == "" {
+		return false
+	}
+	return a.Permission(pageId, parentID).Includes(PermissionView)
+}
+
+// CanEdit reports whether the viewer may change a page. Editing needs the editor role
+// as well, an ACL only narrows who may edit.
+func (a *PageAccess) CanEdit(pageId int, parentID *int) bool {
+	if a.viewer.Role != roles.Editor && a.viewer.Role != roles.Admin {
+		return false
+	}
+	return a.Permission(pageId, parentID).Includes(PermissionEdit)
+}
+
+// CanEditSubtree reports whether the viewer may change a page and all of its descendants.
+func (a *PageAccess) CanEditSubtree(pageId int, parentID *int) bool {
+	if !a.CanEdit(pageId, parentID) {
+		return false
+	}
+	for _, page := range a.pages {
+		if page.ID != pageId && a.isDescendant(page, pageId) && !a.CanEdit(page.ID, page.ParentID) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanCreate reports whether the viewer may add a page below the parent, or a top level
+// page when the parent is nil.
+func (a *PageAccess) CanCreate(parentID *int) bool {
+	if parentID == nil {
+		return a.viewer.Role == roles.Editor || a.viewer.Role == roles.Admin
+	}
+	grandParentID, _ := a.getParentID(*parentID)
+	return a.CanEdit(*parentID, grandParentID)
+}
+
+// CanManage reports whether the viewer may change the ACL of a page.
+func (a *PageAccess) CanManage(pageId int, parentID *int) bool {
+	return a.viewer.UserID != "" && a.Permission(pageId, parentID).Includes(PermissionAdmin)
+}
+
+// FilterPages keeps the pages the viewer may see.
+func (a *PageAccess) FilterPages(pages []*PageMeta) []*PageMeta {
+	result := make([]*PageMeta, 0, len(pages))
+	for _, page := range pages {
+		if a.CanView(page.ID, page.ParentID, page.IsProtected) {
+			result = append(result, page)
+		}
+	}
+	return result
+}
+
+func (a *PageAccess) getParentID(pageId int) (*int, bool) {
+	page, ok := a.pages[pageId]
+	if !ok {
+		return nil, false
+	}
+	return page.ParentID, true
+}
+
+func (a *PageAccess) isDescendant(page *PageMeta, rootID int) bool {
+	visited := make(map[int]bool)
+	for parentID := page.ParentID; parentID != nil && !visited[*parentID]; {
+		if *parentID == rootID {
+			return true
+		}
+		visited[*parentID] = true
+		parentID, _ = a.getParentID(*parentID)
+	}
+	return false
+}
+
+// findAcl returns the ACL of the page or of its closest ancestor that has one.
+func (a *PageAccess) findAcl(pageId int, parentID *int, getParentID func(int) (*int, bool)) *PageAcl {
+	visited := make(map[int]bool)
+	for {
+		if acl := a.acls[pageId]; acl != nil {
+			return acl
+		}
+		visited[pageId] = true
+		if parentID == nil || visited[*parentID] {
+			return nil
+		}
+		pageId = *parentID
+		var ok bool
+		if parentID, ok = getParentID(pageId); !ok {
+			parentID = nil
+		}
+	}
+}
+
+func aclPermission(acl *PageAcl, viewer *PageViewer) PagePermission {
+	permission := PermissionNone
+	for _, entry := range acl.Entries {
+		if viewer.matches(entry) && !permission.Includes(entry.Permission) {
+			permission = entry.Permission
+		}
+	}
+	return permission
+}
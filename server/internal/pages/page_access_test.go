@@ -0,0 +1,72 @@
+package pages
+
+import (
+	"testing"
+
+	"wikigo/internal/roles"
+)
+
+func TestPageAccessPermission(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	// 1 (restricted to the docs group) > 2 (open to alice) > 3, and 4 without an ACL
+	pageList := []*PageMeta{
+		{ID: 1},
+		{ID: 2, ParentID: intPtr(1)},
+		{ID: 3, ParentID: intPtr(2)},
+		{ID: 4},
+	}
+	acls := map[int]*PageAcl{
+		1: {PageID: 1, Entries: []*PageAclEntry{
+			{PrincipalType: PrincipalGroup, Principal: "docs", Permission: PermissionView},
+			{PrincipalType: PrincipalUser, Principal: "bob", Permission: PermissionAdmin},
+		}},
+		2: {PageID: 2, Entries: []*PageAclEntry{
+			{PrincipalType: PrincipalUser, Principal: "Alice", Permission: PermissionEdit},
+		}},
+	}
+	newAccess := func(viewer *PageViewer) *PageAccess {
+		access := &PageAccess{viewer: viewer, acls: acls, pages: make(map[int]*PageMeta), permissions: make(map[int]PagePermission)}
+		for _, page := range pageList {
+			access.pages[page.ID] = page
+		}
+		return access
+	}
+	tests := []struct {
+		name     string
+		viewer   *PageViewer
+		pageId   int
+		expected PagePermission
+	}{
+		{"Anonymous without ACL", &PageViewer{}, 4, PermissionView},
+		{"Anonymous with ACL", &PageViewer{}, 1, PermissionNone},
+		{"Group member", &PageViewer{UserID: "carol", Role: roles.Editor, Groups: []string{"docs"}}, 1, PermissionView},
+		{"Closest ACL applies", &PageViewer{UserID: "carol", Role: roles.Editor, Groups: []string{"docs"}}, 3, PermissionNone},
+		{"Inherited user entry", &PageViewer{UserID: "alice", Role: roles.Editor}, 3, PermissionEdit},
+		{"User not in ACL", &PageViewer{UserID: "alice", Role: roles.Editor}, 1, PermissionNone},
+		{"Editor without ACL", &PageViewer{UserID: "alice", Role: roles.Editor}, 4, PermissionEdit},
+		{"Global admin", &PageViewer{UserID: "root", Role: roles.Admin}, 3, PermissionAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access := newAccess(tt.viewer)
+			page := access.pages[tt.pageId]
+			if got := access.Permission(page.ID, page.ParentID); got != tt.expected {
+				t.Errorf("Permission() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	reader := newAccess(&PageViewer{UserID: "bob", Role: roles.Reader})
+	if reader.CanEdit(1, nil) {
+		t.Error("CanEdit() = true for a reader with admin permission, want false")
+	}
+	if !reader.CanManage(1, nil) {
+		t.Error("CanManage() = false for a user with admin permission, want true")
+	}
+	if reader.CanEditSubtree(1, nil) {
+		t.Error("CanEditSubtree() = true for a reader, want false")
+	}
+	if got := len(newAccess(&PageViewer{}).FilterPages(pageList)); got != 1 {
+		t.Errorf("FilterPages() for anonymous returned %d pages, want 1", got)
+	}
+}
@@ -0,0 +1,67 @@
+package pages
+
+import (
+	"strconv"
+	"time"
+)
+
+type PagePermission string
+
+const (
+	PermissionNone  PagePermission = ""
+	PermissionView  PagePermission = "view"
+	PermissionEdit  PagePermission = "edit"
+	PermissionAdmin PagePermission = "admin"
+)
+
+var permissionRanks = map[PagePermission]int{
+	PermissionNone:  0,
+	PermissionView:  1,
+	PermissionEdit:  2,
+	PermissionAdmin: 3,
+}
+
+// Includes reports whether the permission grants at least the other permission.
+func (p PagePermission) Includes(other PagePermission) bool {
+	return permissionRanks[p] >= permissionRanks[other]
+}
+
+type PrincipalType string
+
+const (
+	PrincipalUser  PrincipalType = "user"
+	PrincipalGroup PrincipalType = "group"
+)
+
+// PageAclEntry grants a permission on a page and its subtree to a user or a group.
+type PageAclEntry struct {
+	PrincipalType PrincipalType  `json:"principalType" validate:"required,oneof=user group"`
+	Principal     string         `json:"principal" validate:"required,max=100"`
+	Permission    PagePermission `json:"permission" validate:"required,oneof=view edit admin"`
+}
+
+// PageAcl restricts a page and the pages below it to the users and groups of its entries.
+// The ACL closest to a page in the tree applies; pages without one follow the global roles.
+type PageAcl struct {
+	ID        int             `json:"id"`
+	PageID    int             `json:"pageId" validate:"required"`
+	Entries   []*PageAclEntry `json:"entries" validate:"dive"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+	UpdatedBy string          `json:"updatedBy"`
+}
+
+func (a *PageAcl) GetID() int {
+	return a.ID
+}
+
+func (a *PageAcl) SetID(id int) {
+	a.ID = id
+}
+
+func (a *PageAcl) GetValue(field string) string {
+	switch field {
+	case "PageID":
+		return strconv.Itoa(a.PageID)
+	}
+	return ""
+}
@@ -0,0 +1,9 @@
+package pages
+
+type PageAclRepository interface {
+	Init() error
+	GetPageAcl(pageId int) (*PageAcl, error)
+	ListPageAcls() ([]*PageAcl, error)
+	SavePageAcl(acl *PageAcl) error
+	DeletePageAcl(pageId int) error
+}
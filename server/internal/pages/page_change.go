@@ -0,0 +1,65 @@
+package pages
+
+import (
+	"strconv"
+	"time"
+)
+
+type ChangeAction string
+
+const (
+	ChangeActionCreate  ChangeAction = "create"
+	ChangeActionUpdate  ChangeAction = "update"
+	ChangeActionDelete  ChangeAction = "delete"
+	ChangeActionRestore ChangeAction = "restore"
+)
+
+// PageChange is an entry of the recent changes log. Title, Url, ParentID and
+// IsProtected are copied from the page at the time of the change so deleted pages
+// can still be listed.
+type PageChange struct {
+	ID          int          `json:"id"`
+	PageID      int          `json:"pageId"`
+	ParentID    *int         `json:"parentId"`
+	Action      ChangeAction `json:"action"`
+	Title       string       `json:"title"`
+	Url         string       `json:"url"`
+	Author      string       `json:"author"`
+	Comment     string       `json:"comment"`
+	ChangeDate  time.Time    `json:"changeDate"`
+	IsProtected bool         `json:"isProtected"`
+}
+
+func (c *PageChange) GetID() int {
+	return c.ID
+}
+
+func (c *PageChange) SetID(id int) {
+	c.ID = id
+}
+
+func (c *PageChange) GetValue(field string) string {
+	switch field {
+	case "PageID":
+		return strconv.Itoa(c.PageID)
+	case "ParentID":
+		if c.ParentID != nil {
+			return strconv.Itoa(*c.ParentID)
+		}
+	case "Action":
+		return string(c.Action)
+	case "Title":
+		return c.Title
+	case "Url":
+		return c.Url
+	case "Author":
+		return c.Author
+	case "Comment":
+		return c.Comment
+	case "ChangeDate":
+		return c.ChangeDate.Format(time.RFC3339Nano)
+	case "IsProtected":
+		return strconv.FormatBool(c.IsProtected)
+	}
+	return ""
+}
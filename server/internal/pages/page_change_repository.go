@@ -0,0 +1,8 @@
+package pages
+
+type PageChangeRepository interface {
+	Init() error
+	AddChange(change *PageChange) error
+	ListChanges() ([]*PageChange, error)
+	ListChangesByAuthor(author string) ([]*PageChange, error)
+}
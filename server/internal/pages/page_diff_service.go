@@ -0,0 +1,319 @@
+package pages
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"wikigo/internal/common/errors"
+	"wikigo/internal/revisions"
+)
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+type DiffSegment struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// PageVersion identifies one side of a diff. RevisionID is 0 for the current page.
+type PageVersion struct {
+	RevisionID int       `json:"revisionId"`
+	Date       time.Time `json:"date"`
+	Title      string    `json:"title"`
+}
+
+type PageDiff struct {
+	PageID  int            `json:"pageId"`
+	From    *PageVersion   `json:"from"`
+	To      *PageVersion   `json:"to"`
+	Fields  []*FieldChange `json:"fields"`
+	Content []*DiffSegment `json:"content"`
+}
+
+var (
+	diffFields        = []string{"Title", "Url", "ShortDesc", "Tags", "ParentID", "IsProtected", "IsPinned", "IsCategoryPage", "SortChildrenDesc"}
+	htmlDiffTokenizer = regexp.MustCompile(`<[^>]*>|\s+|[^\s<]+`)
+)
+
+type PageDiffService struct {
+	DB              PageRepository
+	RevisionService *revisions.RevisionService[*Page]
+}
+
+// Diff compares two versions of a page. A revision ID of 0 refers to the current page.
+func (s *PageDiffService) Diff(pageID int, fromRevisionID int, toRevisionID int) (*PageDiff, error) {
+	from, fromVersion, err := s.getVersion(pageID, fromRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	to, toVersion, err := s.getVersion(pageID, toRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	return &PageDiff{
+		PageID:  pageID,
+		From:    fromVersion,
+		To:      toVersion,
+		Fields:  DiffPageFields(from, to),
+		Content: DiffHtml(from.Content, to.Content),
+	}, nil
+}
+
+func (s *PageDiffService) getVersion(pageID int, revisionID int) (*Page, *PageVersion, error) {
+	if revisionID == 0 {
+		page, err := s.DB.GetPageByID(pageID)
+		if err != nil || page == nil {
+			return nil, nil, errors.NotFound("page not found")
+		}
+		return page, &PageVersion{Date: page.LastModifiedAt, Title: page.Title}, nil
+	}
+	revision, err := s.RevisionService.GetRevision(revisionID)
+	if err != nil || revision == nil || revision.RecordID != pageID || revision.Record == nil {
+		return nil, nil, errors.NotFound("revision not found")
+	}
+	return revision.Record, &PageVersion{
+		RevisionID: revision.ID,
+		Date:       revision.Record.LastModifiedAt,
+		Title:      revision.Record.Title,
+	}, nil
+}
+
+// DiffPageFields lists the metadata fields that differ between two pages.
+func DiffPageFields(from, to *Page) []*FieldChange {
+	changes := make([]*FieldChange, 0)
+	for _, field := range diffFields {
+		fromValue, toValue := diffFieldValue(from, field), diffFieldValue(to, field)
+		if fromValue != toValue {
+			changes = append(changes, &FieldChange{Field: field, From: fromValue, To: toValue})
+		}
+	}
+	return changes
+}
+
+func diffFieldValue(page *Page, field string) string {
+	switch field {
+	case "ShortDesc":
+		return page.ShortDesc
+	case "Tags":
+		return strings.Join(page.Tags, ", ")
+	}
+	return page.GetValue(field)
+}
+
+// DiffHtml produces a word level diff of two HTML fragments. Tags are kept as whole
+// tokens so that every segment can be rendered back as HTML.
+func DiffHtml(from, to string) []*DiffSegment {
+	return diffTokens(htmlDiffTokenizer.FindAllString(from, -1), htmlDiffTokenizer.FindAllString(to, -1))
+}
+
+func diffTokens(a, b []string) []*DiffSegment {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffTokenOp, 0, len(a)+len(b))
+	for _, token := range a[:prefix] {
+		ops = append(ops, diffTokenOp{DiffEqual, token})
+	}
+	ops = append(ops, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, token := range a[len(a)-suffix:] {
+		ops = append(ops, diffTokenOp{DiffEqual, token})
+	}
+	alignToBoundaries(ops)
+
+	segments := make([]*DiffSegment, 0)
+	for start := 0; start < len(ops); {
+		end := start
+		tokens := make([]string, 0)
+		for end < len(ops) && ops[end].op == ops[start].op {
+			tokens = append(tokens, ops[end].token)
+			end++
+		}
+		segments = appendSegment(segments, ops[start].op, tokens...)
+		start = end
+	}
+	return segments
+}
+
+// alignToBoundaries slides each pure insertion or deletion along the equal tokens around it,
+// which does not change the result, so that it starts and ends at a tag where possible.
+// For example "<p>One</p><p>" + "Two</p><p>" becomes "<p>One</p>" + "<p>Two</p>".
+func alignToBoundaries(ops []diffTokenOp) {
+	for start := 0; start < len(ops); {
+		op := ops[start].op
+		if op == DiffEqual {
+			start++
+			continue
+		}
+		end := start
+		for end < len(ops) && ops[end].op == op {
+			end++
+		}
+		if (start > 0 && ops[start-1].op != DiffEqual) || (end < len(ops) && ops[end].op != DiffEqual) {
+			start = end
+			continue
+		}
+		left, right := start, end
+		for left > 0 && ops[left-1].op == DiffEqual && ops[left-1].token == ops[right-1].token {
+			left--
+			right--
+		}
+		best, bestScore := left, boundaryScore(ops[left].token, ops[right-1].token)
+		for right < len(ops) && (right < end || ops[right].op == DiffEqual) && ops[right].token == ops[left].token {
+			left++
+			right++
+			if score := boundaryScore(ops[left].token, ops[right-1].token); score > bestScore {
+				best, bestScore = left, score
+			}
+		}
+		length := end - start
+		for i := min(start, best); i < max(end, best+length); i++ {
+			if i >= best && i < best+length {
+				ops[i].op = op
+			} else {
+				ops[i].op = DiffEqual
+			}
+		}
+		start = max(end, best+length)
+	}
+}
+
+func boundaryScore(first, last string) int {
+	score := 0
+	if strings.HasPrefix(first, "<") && !strings.HasPrefix(first, "</") {
+		score += 2
+	} else if strings.TrimSpace(first) == "" {
+		score++
+	}
+	if strings.HasPrefix(last, "</") {
+		score += 2
+	} else if strings.TrimSpace(last) == "" {
+		score++
+	}
+	return score
+}
+
+func appendSegment(segments []*DiffSegment, op DiffOp, tokens ...string) []*DiffSegment {
+	if len(tokens) == 0 {
+		return segments
+	}
+	text := strings.Join(tokens, "")
+	if n := len(segments); n > 0 && segments[n-1].Op == op {
+		segments[n-1].Text += text
+		return segments
+	}
+	return append(segments, &DiffSegment{Op: op, Text: text})
+}
+
+type diffTokenOp struct {
+	op    DiffOp
+	token string
+}
+
+// maxDiffEdits bounds the edit script myersDiff searches for. The trace kept for the
+// backtrack grows with the square of the edits, so versions differing in more tokens are
+// shown as the old content replaced by the new one.
+const maxDiffEdits = 2000
+
+// myersDiff implements the Myers O(ND) shortest edit script algorithm.
+func myersDiff(a, b []string) []diffTokenOp {
+	n, m := len(a), len(b)
+	total := n + m
+	if total == 0 {
+		return nil
+	}
+	offset := total + 1
+	v := make([]int, 2*total+3)
+	// trace[d] holds the diagonals -d-1..d+1 of v before step d, the only ones the
+	// backtrack reads
+	trace := make([][]int, 0)
+	found := false
+
+done:
+	for d := 0; d <= min(total, maxDiffEdits); d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break done
+			}
+		}
+	}
+	if !found {
+		return replaceTokens(a, b)
+	}
+
+	ops := make([]diffTokenOp, 0, total)
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k+d] < v[k+d+2]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d+1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffTokenOp{DiffEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffTokenOp{DiffInsert, b[y-1]})
+			} else {
+				ops = append(ops, diffTokenOp{DiffDelete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func replaceTokens(a, b []string) []diffTokenOp {
+	ops := make([]diffTokenOp, 0, len(a)+len(b))
+	for _, token := range a {
+		ops = append(ops, diffTokenOp{DiffDelete, token})
+	}
+	for _, token := range b {
+		ops = append(ops, diffTokenOp{DiffInsert, token})
+	}
+	return ops
+}
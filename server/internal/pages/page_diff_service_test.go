@@ -0,0 +1,131 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffHtml(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected []DiffSegment
+	}{
+		{
+			name:     "Identical content",
+			from:     "<p>Hello world</p>",
+			to:       "<p>Hello world</p>",
+			expected: []DiffSegment{{DiffEqual, "<p>Hello world</p>"}},
+		},
+		{
+			name: "Word replaced",
+			from: "<p>Hello world</p>",
+			to:   "<p>Hello there</p>",
+			expected: []DiffSegment{
+				{DiffEqual, "<p>Hello "},
+				{DiffDelete, "world"},
+				{DiffInsert, "there"},
+				{DiffEqual, "</p>"},
+			},
+		},
+		{
+			name: "Block inserted",
+			from: "<p>One</p><p>Three</p>",
+			to:   "<p>One</p><p>Two</p><p>Three</p>",
+			expected: []DiffSegment{
+				{DiffEqual, "<p>One</p>"},
+				{DiffInsert, "<p>Two</p>"},
+				{DiffEqual, "<p>Three</p>"},
+			},
+		},
+		{
+			name:     "From empty",
+			from:     "",
+			to:       "<p>New</p>",
+			expected: []DiffSegment{{DiffInsert, "<p>New</p>"}},
+		},
+		{
+			name:     "To empty",
+			from:     "<p>Old</p>",
+			to:       "",
+			expected: []DiffSegment{{DiffDelete, "<p>Old</p>"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DiffHtml(tt.from, tt.to)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("DiffHtml(%q, %q) = %v, expected %v", tt.from, tt.to, result, tt.expected)
+			}
+			for i, segment := range result {
+				if *segment != tt.expected[i] {
+					t.Errorf("segment %d = %+v, expected %+v", i, *segment, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiffHtmlReconstructsBothSides(t *testing.T) {
+	from := "<h1>Title</h1><p>The quick brown fox</p><ul><li>a</li><li>b</li></ul>"
+	to := "<h1>New title</h1><p>The slow brown fox jumps</p><ul><li>b</li><li>c</li></ul>"
+	var gotFrom, gotTo string
+	for _, segment := range DiffHtml(from, to) {
+		if segment.Op != DiffInsert {
+			gotFrom += segment.Text
+		}
+		if segment.Op != DiffDelete {
+			gotTo += segment.Text
+		}
+	}
+	if gotFrom != from {
+		t.Errorf("from side = %q, expected %q", gotFrom, from)
+	}
+	if gotTo != to {
+		t.Errorf("to side = %q, expected %q", gotTo, to)
+	}
+}
+
+func TestDiffHtmlFallsBackToReplaceForLargeRewrites(t *testing.T) {
+	var from, to strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&from, "<p>old%d</p>", i)
+		fmt.Fprintf(&to, "<p>new%d</p>", i)
+	}
+	var gotFrom, gotTo strings.Builder
+	for _, segment := range DiffHtml(from.String(), to.String()) {
+		if segment.Op != DiffInsert {
+			gotFrom.WriteString(segment.Text)
+		}
+		if segment.Op != DiffDelete {
+			gotTo.WriteString(segment.Text)
+		}
+	}
+	if gotFrom.String() != from.String() || gotTo.String() != to.String() {
+		t.Error("DiffHtml() does not reconstruct both sides of a large rewrite")
+	}
+}
+
+func TestDiffPageFields(t *testing.T) {
+	parentID := 3
+	from := &Page{Title: "Guide", Url: "/guide", Tags: []string{"a"}}
+	to := &Page{Title: "Guide", Url: "/guides", Tags: []string{"a", "b"}, ParentID: &parentID, IsProtected: true}
+	changes := DiffPageFields(from, to)
+	expected := map[string]FieldChange{
+		"Url":         {"Url", "/guide", "/guides"},
+		"Tags":        {"Tags", "a", "a, b"},
+		"ParentID":    {"ParentID", "", "3"},
+		"IsProtected": {"IsProtected", "false", "true"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("DiffPageFields returned %d changes, expected %d", len(changes), len(expected))
+	}
+	for _, change := range changes {
+		if *change != expected[change.Field] {
+			t.Errorf("change %+v, expected %+v", *change, expected[change.Field])
+		}
+	}
+}
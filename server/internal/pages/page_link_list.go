@@ -0,0 +1,27 @@
+package pages
+
+// PageLinkList holds the pages whose content links to a page url.
+// Links are kept by url so links to pages that do not exist yet are indexed too.
+type PageLinkList struct {
+	ID      int    `json:"id"`
+	Url     string `json:"url" validate:"required"`
+	PageIds []int  `json:"page_ids" validate:"required"`
+}
+
+func (l *PageLinkList) GetID() int {
+	return l.ID
+}
+
+func (l *PageLinkList) SetID(id int) {
+	l.ID = id
+}
+
+func (l *PageLinkList) GetValue(field string) string {
+	switch field {
+	case "Url":
+		return l.Url
+	case "PageIds":
+		return intArrayToString(l.PageIds)
+	}
+	return ""
+}
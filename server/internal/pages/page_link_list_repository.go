@@ -0,0 +1,8 @@
+package pages
+
+type PageLinkListRepository interface {
+	Init() error
+	GetPageLinkList(url string) (*PageLinkList, error)
+	UpdatePageLinkLists(urls, oldUrls []string, pageId int) error
+	DeleteAll() error
+}
@@ -1,11 +1,14 @@
 package pages
 
+import "time"
+
 type PageMeta struct {
-	ID               int    `json:"id"`
-	ParentID         *int   `json:"parentId"`
-	Url              string `json:"url"`
-	Title            string `json:"title"`
-	IsPinned         bool   `json:"isPinned"`
-	IsProtected      bool   `json:"isProtected"`
-	SortChildrenDesc bool   `json:"sortChildrenDesc"`
+	ID               int        `json:"id"`
+	ParentID         *int       `json:"parentId"`
+	Url              string     `json:"url"`
+	Title            string     `json:"title"`
+	IsPinned         bool       `json:"isPinned"`
+	IsProtected      bool       `json:"isProtected"`
+	SortChildrenDesc bool       `json:"sortChildrenDesc"`
+	DeletedAt        *time.Time `json:"deletedAt,omitempty"`
 }
@@ -0,0 +1,279 @@
+package pages
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"wikigo/internal/common/errors"
+)
+
+type PageMove struct {
+	ID      int    `json:"id"`
+	FromUrl string `json:"fromUrl"`
+	ToUrl   string `json:"toUrl"`
+}
+
+var (
+	pageUrlRegexp      = regexp.MustCompile(`^(([/])|(([/][a-zA-Z0-9-]+)+))$`)
+	internalLinkRegexp = regexp.MustCompile(`href="/p(/[^"#?]*)([#?][^"]*)?"`)
+)
+
+// MovePage changes the url of a page together with the urls of its descendants that
+// share the same prefix, e.g. moving /guides to /handbook/guides also moves /guides/setup
+// to /handbook/guides/setup. When parentID is nil the parent is taken from the new url
+// if a page exists there. Links in page content are rewritten and the old urls redirect
+// to the new ones. Every url and page is checked before the first page is saved, and
+// the saved pages are put back if a later one fails, so a move is not left half done.
+func (s *PageService) MovePage(id int, newUrl string, parentID *int, user string) ([]*PageMove, error) {
+	page, err := s.DB.GetPageByID(id)
+	if err != nil || page == nil || page.IsDeleted() {
+		return nil, errors.NotFound("page not found")
+	}
+	if page.Url == "/" {
+		return nil, errors.NewValidationError("the home page cannot be moved", "Url")
+	}
+	if !pageUrlRegexp.MatchString(newUrl) || newUrl == "/" {
+		return nil, errors.NewValidationError("invalid url", "Url")
+	}
+
+	subtree, err := s.getSubtree(page)
+	if err != nil {
+		return nil, err
+	}
+	inSubtree := make(map[int]bool, len(subtree))
+	for _, p := range subtree {
+		inSubtree[p.ID] = true
+	}
+
+	if parentID == nil {
+		if parent, err := s.DB.GetPageByUrl(parentUrl(newUrl)); err != nil {
+			return nil, err
+		} else if parent != nil && !parent.IsDeleted() && !inSubtree[parent.ID] && parent.Url != "/" {
+			parentID = &parent.ID
+		} else {
+			parentID = page.ParentID
+		}
+	} else if inSubtree[*parentID] {
+		return nil, errors.NewValidationError("cannot move a page under itself", "ParentID")
+	} else if parent, err := s.DB.GetPageByID(*parentID); err != nil {
+		return nil, err
+	} else if parent == nil || parent.IsDeleted() {
+		return nil, errors.NewValidationError("parent page not found", "ParentID")
+	}
+
+	moves := make([]*PageMove, 0)
+	urlMap := make(map[string]string)
+	for _, p := range subtree {
+		var toUrl string
+		if p.ID == page.ID {
+			toUrl = newUrl
+		} else if strings.HasPrefix(p.Url, page.Url+"/") {
+			toUrl = newUrl + strings.TrimPrefix(p.Url, page.Url)
+		} else {
+			continue
+		}
+		if toUrl == p.Url {
+			continue
+		}
+		if len(toUrl) > 100 {
+			return nil, errors.NewValidationError("url is too long: "+toUrl, "Url")
+		}
+		existing, err := s.DB.GetPageByUrl(toUrl)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && !inSubtree[existing.ID] {
+			return nil, errors.NewValidationError("url is already used: "+toUrl, "Url")
+		}
+		moves = append(moves, &PageMove{ID: p.ID, FromUrl: p.Url, ToUrl: toUrl})
+		urlMap[p.Url] = toUrl
+	}
+	moves, err = orderMoves(moves)
+	if err != nil {
+		return nil, err
+	}
+
+	updates, err := s.prepareMoves(page, moves, urlMap, parentID)
+	if err != nil {
+		return nil, err
+	}
+	linkUpdates, err := s.prepareLinkUpdates(inSubtree, urlMap)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPageUpdates(append(updates, linkUpdates...), user); err != nil {
+		return nil, err
+	}
+	for _, move := range moves {
+		if err := s.RedirectService.AddMoveRedirect(move.FromUrl, move.ToUrl, user); err != nil {
+			return nil, err
+		}
+	}
+	return moves, nil
+}
+
+// pageUpdate is a change to a page that is saved as part of a move, with the page as it
+// was before so the change can be undone.
+type pageUpdate struct {
+	page     *Page
+	original *Page
+	comment  string
+}
+
+// orderMoves sorts the moves so a page is only moved once its new url is no longer held
+// by another page of the subtree, and the unique url index is never violated.
+func orderMoves(moves []*PageMove) ([]*PageMove, error) {
+	pending := make(map[string]*PageMove, len(moves))
+	for _, move := range moves {
+		pending[move.FromUrl] = move
+	}
+	ordered := make([]*PageMove, 0, len(moves))
+	for len(pending) > 0 {
+		progress := false
+		for _, move := range moves {
+			if pending[move.FromUrl] != move || pending[move.ToUrl] != nil {
+				continue
+			}
+			ordered = append(ordered, move)
+			delete(pending, move.FromUrl)
+			progress = true
+		}
+		if !progress {
+			return nil, errors.NewValidationError("pages cannot be moved because their urls overlap", "Url")
+		}
+	}
+	return ordered, nil
+}
+
+// prepareMoves loads the moved pages and sets their new url, parent and links without
+// saving them. The root page is updated even when its url stays the same, as its parent
+// may change.
+func (s *PageService) prepareMoves(root *Page, moves []*PageMove, urlMap map[string]string, parentID *int) ([]*pageUpdate, error) {
+	if len(moves) == 0 {
+		original := *root
+		root.ParentID = parentID
+		return []*pageUpdate{{page: root, original: &original, comment: "Moved page"}}, nil
+	}
+	updates := make([]*pageUpdate, 0, len(moves))
+	for _, move := range moves {
+		p, err := s.DB.GetPageByID(move.ID)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, errors.NotFound("page not found")
+		}
+		original := *p
+		p.Url = move.ToUrl
+		p.Content = RewriteInternalLinks(p.Content, urlMap)
+		if p.ID == root.ID {
+			p.ParentID = parentID
+		}
+		if err := ValidatePage(p, false); err != nil {
+			return nil, err
+		}
+		updates = append(updates, &pageUpdate{page: p, original: &original, comment: "Moved from " + move.FromUrl})
+	}
+	return updates, nil
+}
+
+// prepareLinkUpdates rewrites the links to the moved urls in the pages outside the moved
+// subtree. The linking pages are found in the link index.
+func (s *PageService) prepareLinkUpdates(skip map[int]bool, urlMap map[string]string) ([]*pageUpdate, error) {
+	urls := make([]string, 0, len(urlMap))
+	for url := range urlMap {
+		urls = append(urls, url)
+	}
+	pageIds, err := s.LinkService.GetLinkingPageIds(urls)
+	if err != nil {
+		return nil, err
+	}
+	updates := make([]*pageUpdate, 0)
+	for _, pageId := range pageIds {
+		if skip[pageId] {
+			continue
+		}
+		p, err := s.DB.GetPageByID(pageId)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil || p.IsDeleted() {
+			continue
+		}
+		content := RewriteInternalLinks(p.Content, urlMap)
+		if content == p.Content {
+			continue
+		}
+		original := *p
+		p.Content = content
+		updates = append(updates, &pageUpdate{page: p, original: &original, comment: "Updated links to moved pages"})
+	}
+	return updates, nil
+}
+
+// applyPageUpdates saves the pages in order. When one of them fails, the pages saved
+// before it are put back in reverse order, which frees their new urls again.
+func (s *PageService) applyPageUpdates(updates []*pageUpdate, user string) error {
+	for i, update := range updates {
+		err := s.UpdatePage(update.page, user, update.comment)
+		if err == nil {
+			continue
+		}
+		// The page itself may have been saved before a later step of UpdatePage failed
+		if current, getErr := s.DB.GetPageByID(update.page.ID); getErr == nil && current != nil &&
+			sameMove(current, update.page) && !sameMove(current, update.original) {
+			i++
+		}
+		for j := i - 1; j >= 0; j-- {
+			if undoErr := s.UpdatePage(updates[j].original, user, "Reverted failed move"); undoErr != nil {
+				log.Printf("failed to revert page %d after a failed move: %v\n", updates[j].page.ID, undoErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// sameMove reports whether two versions of a page have the same url, parent and content.
+func sameMove(a, b *Page) bool {
+	sameParent := a.ParentID == nil && b.ParentID == nil || a.ParentID != nil && b.ParentID != nil && *a.ParentID == *b.ParentID
+	return a.Url == b.Url && a.Content == b.Content && sameParent
+}
+
+func (s *PageService) getSubtree(root *Page) ([]*PageMeta, error) {
+	result := []*PageMeta{{ID: root.ID, ParentID: root.ParentID, Url: root.Url, Title: root.Title}}
+	visited := map[int]bool{root.ID: true}
+	for i := 0; i < len(result); i++ {
+		children, err := s.DB.GetPagesByParentID(&result[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if !visited[child.ID] {
+				visited[child.ID] = true
+				result = append(result, child)
+			}
+		}
+	}
+	return result, nil
+}
+
+// RewriteInternalLinks replaces /p/... hrefs whose page url is a key of urlMap.
+func RewriteInternalLinks(content string, urlMap map[string]string) string {
+	return internalLinkRegexp.ReplaceAllStringFunc(content, func(link string) string {
+		match := internalLinkRegexp.FindStringSubmatch(link)
+		if newUrl, ok := urlMap[match[1]]; ok {
+			return `href="/p` + newUrl + match[2] + `"`
+		}
+		return link
+	})
+}
+
+func parentUrl(url string) string {
+	i := strings.LastIndex(url, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return url[:i]
+}
@@ -0,0 +1,88 @@
+package pages
+
+import (
+	"testing"
+)
+
+func TestRewriteInternalLinks(t *testing.T) {
+	urlMap := map[string]string{
+		"/guides":       "/handbook/guides",
+		"/guides/setup": "/handbook/guides/setup",
+	}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Moved page",
+			input:    `<a href="/p/guides">Guides</a>`,
+			expected: `<a href="/p/handbook/guides">Guides</a>`,
+		},
+		{
+			name:     "Moved child page with anchor",
+			input:    `<a href="/p/guides/setup#install">Install</a>`,
+			expected: `<a href="/p/handbook/guides/setup#install">Install</a>`,
+		},
+		{
+			name:     "Page with the same prefix that was not moved",
+			input:    `<a href="/p/guides-old">Old</a>`,
+			expected: `<a href="/p/guides-old">Old</a>`,
+		},
+		{
+			name:     "External link",
+			input:    `<a href="https://example.com/p/guides">External</a>`,
+			expected: `<a href="https://example.com/p/guides">External</a>`,
+		},
+		{
+			name:     "Multiple links",
+			input:    `<p><a href="/p/guides">A</a> and <a href="/p/guides/setup">B</a></p>`,
+			expected: `<p><a href="/p/handbook/guides">A</a> and <a href="/p/handbook/guides/setup">B</a></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := RewriteInternalLinks(tt.input, urlMap)
+			if result != tt.expected {
+				t.Errorf("RewriteInternalLinks(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParentUrl(t *testing.T) {
+	tests := map[string]string{
+		"/handbook/guides": "/handbook",
+		"/handbook":        "/",
+		"/":                "/",
+	}
+	for input, expected := range tests {
+		if result := parentUrl(input); result != expected {
+			t.Errorf("parentUrl(%q) = %q, expected %q", input, result, expected)
+		}
+	}
+}
+
+func TestOrderMoves(t *testing.T) {
+	// The new url of page 2 is only free once page 1 has moved away from it
+	moves := []*PageMove{
+		{ID: 2, FromUrl: "/a/a", ToUrl: "/a"},
+		{ID: 1, FromUrl: "/a", ToUrl: "/b"},
+	}
+	ordered, err := orderMoves(moves)
+	if err != nil {
+		t.Fatalf("orderMoves() error = %v", err)
+	}
+	if len(ordered) != 2 || ordered[0].ID != 1 || ordered[1].ID != 2 {
+		t.Errorf("orderMoves() = %+v, expected page 1 before page 2", ordered)
+	}
+
+	swap := []*PageMove{
+		{ID: 1, FromUrl: "/a", ToUrl: "/b"},
+		{ID: 2, FromUrl: "/b", ToUrl: "/a"},
+	}
+	if _, err := orderMoves(swap); err == nil {
+		t.Error("orderMoves() of swapped urls did not fail")
+	}
+}
@@ -7,6 +7,7 @@ type PageRepository interface {
 	GetPagesByAuthor(author string) ([]*PageMeta, error)
 	GetPagesByParentID(parentID *int) ([]*PageMeta, error)
 	GetAllPages(includeProtected bool) ([]*PageMeta, error)
+	GetDeletedPages() ([]*PageMeta, error)
 	CreatePage(page *Page) error
 	UpdatePage(page *Page) error
 	DeletePage(id int) error
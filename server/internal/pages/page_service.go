@@ -1,7 +1,7 @@
 package pages
 
 import (
-	"regexp"
+	"strconv"
 	"time"
 
 	"wikigo/internal/common/errors"
@@ -14,14 +14,32 @@ type PageService struct {
 	DB              PageRepository
 	RevisionService *revisions.RevisionService[*Page]
 	SearchService   *SearchService
+	ChangeService   *RecentChangeService
+	RedirectService *RedirectService
+	LinkService     *LinkService
+	AccessService   *PageAccessService
 }
 
 func (s *PageService) GetPageByID(id int) (*Page, error) {
-	return s.DB.GetPageByID(id)
+	page, err := s.DB.GetPageByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil || page.IsDeleted() {
+		return nil, errors.NotFound("page not found")
+	}
+	return page, nil
 }
 
 func (s *PageService) GetPageByUrl(url string) (*Page, error) {
-	return s.DB.GetPageByUrl(url)
+	page, err := s.DB.GetPageByUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil || page.IsDeleted() {
+		return nil, errors.NotFound("page not found")
+	}
+	return page, nil
 }
 
 func (s *PageService) GetPagesByAuthor(author string) ([]*PageMeta, error) {
@@ -45,10 +63,15 @@ func (s *PageService) CreatePage(page *Page, user string) error {
 		if err != nil {
 			return err
 		}
-		if parent == nil {
+		if parent == nil || parent.IsDeleted() {
 			return errors.NewValidationError("parent page not found", "ParentID")
 		}
 	}
+	if existing, err := s.DB.GetPageByUrl(page.Url); err != nil {
+		return err
+	} else if existing != nil && existing.IsDeleted() {
+		return errors.NewValidationError("url is used by a page in the recycle bin", "Url")
+	}
 	page.CreatedAt = time.Now()
 	page.CreatedBy = user
 	page.LastModifiedAt = page.CreatedAt
@@ -57,14 +80,28 @@ func (s *PageService) CreatePage(page *Page, user string) error {
 	if err == nil {
 		err = s.SearchService.AddPageSearchTerms(page)
 	}
+	if err == nil {
+		err = s.LinkService.AddPageLinks(page)
+	}
+	if err == nil {
+		err = s.RedirectService.RemoveRedirectFrom(page.Url)
+	}
+	if err == nil {
+		err = s.ChangeService.AddChange(page, ChangeActionCreate, user, "")
+	}
 	return err
 }
 
-func (s *PageService) UpdatePage(page *Page, user string) error {
+// UpdatePage saves a page and keeps the replaced version as a revision, recording
+// the editor and an optional edit summary.
+func (s *PageService) UpdatePage(page *Page, user string, comment string) error {
 	oldPage, err := s.DB.GetPageByID(page.ID)
 	if err != nil {
 		return err
 	}
+	if oldPage == nil || oldPage.IsDeleted() {
+		return errors.NotFound("page not found")
+	}
 	if err := ValidatePage(page, false); err != nil {
 		return err
 	}
@@ -79,29 +116,285 @@ func (s *PageService) UpdatePage(page *Page, user string) error {
 	if err != nil {
 		return err
 	}
-	err = s.RevisionService.AddRevision(page.ID, oldPage)
+	err = s.RevisionService.AddRevision(page.ID, oldPage, user, comment, len(page.Content)-len(oldPage.Content))
 	if err == nil {
 		err = s.SearchService.UpdatePageSearchTerms(page, oldPage)
 	}
+	if err == nil {
+		err = s.LinkService.UpdatePageLinks(page, oldPage)
+	}
+	if err == nil && page.Url != oldPage.Url {
+		err = s.RedirectService.RemoveRedirectFrom(page.Url)
+	}
+	if err == nil {
+		err = s.ChangeService.AddChange(page, ChangeActionUpdate, user, comment)
+	}
 	return err
 }
 
-func (s *PageService) DeletePage(id int) error {
+// RestoreRevision brings a page back to the content of one of its revisions.
+// The restore goes through UpdatePage, so the replaced version is kept as a revision too.
+func (s *PageService) RestoreRevision(id int, revisionID int, user string) (*Page, error) {
+	current, err := s.DB.GetPageByID(id)
+	if err != nil || current == nil || current.IsDeleted() {
+		return nil, errors.NotFound("page not found")
+	}
+	revision, err := s.RevisionService.GetRevision(revisionID)
+	if err != nil || revision == nil || revision.RecordID != id || revision.Record == nil {
+		return nil, errors.NotFound("revision not found")
+	}
+	page := *revision.Record
+	page.ID = current.ID
+	page.CreatedAt = current.CreatedAt
+	page.CreatedBy = current.CreatedBy
+	comment := "Restored revision " + strconv.Itoa(revision.ID)
+	if err := s.UpdatePage(&page, user, comment); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+type DeleteMode string
+
+const (
+	// DeleteModeRefuse rejects the deletion when the page has child pages.
+	DeleteModeRefuse DeleteMode = "refuse"
+	// DeleteModeCascade deletes the page together with all of its descendants.
+	DeleteModeCascade DeleteMode = "cascade"
+	// DeleteModeReparent moves the child pages to the parent of the deleted page.
+	DeleteModeReparent DeleteMode = "reparent"
+)
+
+// DeletePage moves a page to the recycle bin. It is hidden from page lists and
+// search until it is restored or purged. The mode decides what happens to its child pages.
+func (s *PageService) DeletePage(id int, user string, mode DeleteMode) error {
 	if id <= 0 {
 		return errors.NewValidationError("invalid page ID", "ID")
 	}
+	if mode == "" {
+		mode = DeleteModeRefuse
+	}
+	if mode != DeleteModeRefuse && mode != DeleteModeCascade && mode != DeleteModeReparent {
+		return errors.NewValidationError("invalid delete mode", "mode")
+	}
 	page, err := s.DB.GetPageByID(id)
-	if err != nil {
+	if err != nil || page == nil || page.IsDeleted() {
 		return errors.NewValidationError("page not found", "ID")
 	}
-	err = s.DB.DeletePage(id)
+	children, err := s.DB.GetPagesByParentID(&page.ID)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		switch mode {
+		case DeleteModeRefuse:
+			return errors.NewValidationError("page has child pages", "ID")
+		case DeleteModeCascade:
+			visited := map[int]bool{page.ID: true}
+			for _, child := range children {
+				if err := s.deleteSubtree(child.ID, user, visited); err != nil {
+					return err
+				}
+			}
+		case DeleteModeReparent:
+			for _, child := range children {
+				childPage, err := s.DB.GetPageByID(child.ID)
+				if err != nil {
+					return err
+				}
+				if childPage == nil {
+					continue
+				}
+				childPage.ParentID = page.ParentID
+				if err := s.UpdatePage(childPage, user, "Moved up from deleted page "+page.Title); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return s.moveToRecycleBin(page, user)
+}
+
+func (s *PageService) deleteSubtree(id int, user string, visited map[int]bool) error {
+	if visited[id] {
+		return nil
+	}
+	visited[id] = true
+	page, err := s.DB.GetPageByID(id)
+	if err != nil || page == nil || page.IsDeleted() {
+		return err
+	}
+	children, err := s.DB.GetPagesByParentID(&page.ID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := s.deleteSubtree(child.ID, user, visited); err != nil {
+			return err
+		}
+	}
+	return s.moveToRecycleBin(page, user)
+}
+
+func (s *PageService) moveToRecycleBin(page *Page, user string) error {
+	now := time.Now()
+	page.DeletedAt = &now
+	page.DeletedBy = user
+	err := s.DB.UpdatePage(page)
 	if err != nil {
 		return err
 	}
 	err = s.SearchService.DeletePageSearchTerms(page)
+	if err == nil {
+		err = s.LinkService.DeletePageLinks(page)
+	}
+	if err == nil {
+		err = s.ChangeService.AddChange(page, ChangeActionDelete, user, "")
+	}
+	return err
+}
+
+// GetOrphanPages finds the pages whose parent page no longer exists or is in the recycle bin.
+// They do not appear in the tree navigation.
+func (s *PageService) GetOrphanPages() ([]*PageMeta, error) {
+	allPages, err := s.DB.GetAllPages(true)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int]bool, len(allPages))
+	for _, page := range allPages {
+		ids[page.ID] = true
+	}
+	orphans := make([]*PageMeta, 0)
+	for _, page := range allPages {
+		if page.ParentID != nil && !ids[*page.ParentID] {
+			orphans = append(orphans, page)
+		}
+	}
+	return orphans, nil
+}
+
+// RepairOrphanPages moves each orphan page under its closest ancestor that still exists,
+// or to the root when there is none.
+func (s *PageService) RepairOrphanPages(user string) ([]*PageMeta, error) {
+	orphans, err := s.GetOrphanPages()
+	if err != nil {
+		return nil, err
+	}
+	for _, orphan := range orphans {
+		page, err := s.DB.GetPageByID(orphan.ID)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil || page.ParentID == nil {
+			continue
+		}
+		page.ParentID, err = s.findExistingAncestor(*page.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.UpdatePage(page, user, "Repaired missing parent page"); err != nil {
+			return nil, err
+		}
+		orphan.ParentID = page.ParentID
+	}
+	return orphans, nil
+}
+
+func (s *PageService) findExistingAncestor(parentID int) (*int, error) {
+	visited := make(map[int]bool)
+	current := &parentID
+	for current != nil && !visited[*current] {
+		visited[*current] = true
+		page, err := s.DB.GetPageByID(*current)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			return nil, nil
+		}
+		if !page.IsDeleted() {
+			return current, nil
+		}
+		current = page.ParentID
+	}
+	return nil, nil
+}
+
+func (s *PageService) GetDeletedPages() ([]*PageMeta, error) {
+	return s.DB.GetDeletedPages()
+}
+
+// RestoreDeletedPage takes a page out of the recycle bin and adds it back to search.
+func (s *PageService) RestoreDeletedPage(id int, user string) error {
+	page, err := s.DB.GetPageByID(id)
+	if err != nil || page == nil || !page.IsDeleted() {
+		return errors.NotFound("page not found in recycle bin")
+	}
+	if page.ParentID != nil {
+		parent, err := s.DB.GetPageByID(*page.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			page.ParentID = nil
+		} else if parent.IsDeleted() {
+			return errors.NewValidationError("parent page is in the recycle bin, restore it first", "ParentID")
+		}
+	}
+	page.DeletedAt = nil
+	page.DeletedBy = ""
+	err = s.DB.UpdatePage(page)
+	if err != nil {
+		return err
+	}
+	err = s.SearchService.AddPageSearchTerms(page)
+	if err == nil {
+		err = s.LinkService.AddPageLinks(page)
+	}
+	if err == nil {
+		err = s.ChangeService.AddChange(page, ChangeActionRestore, user, "")
+	}
 	return err
 }
 
+// PurgePage permanently removes a page in the recycle bin together with its revisions.
+func (s *PageService) PurgePage(id int) error {
+	page, err := s.DB.GetPageByID(id)
+	if err != nil || page == nil || !page.IsDeleted() {
+		return errors.NotFound("page not found in recycle bin")
+	}
+	err = s.DB.DeletePage(id)
+	if err != nil {
+		return err
+	}
+	if s.AccessService != nil {
+		if err := s.AccessService.DeletePageAcl(id); err != nil {
+			return err
+		}
+	}
+	return s.RevisionService.DeleteRevisions(id)
+}
+
+// PurgeExpiredPages purges the pages that have been in the recycle bin longer than retention.
+func (s *PageService) PurgeExpiredPages(retention time.Duration) (int, error) {
+	deleted, err := s.DB.GetDeletedPages()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	expiry := time.Now().Add(-retention)
+	for _, page := range deleted {
+		if page.DeletedAt.Before(expiry) {
+			if err := s.PurgePage(page.ID); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
 func ValidatePage(page *Page, isNew bool) error {
 	if page == nil {
 		return errors.NewValidationError("page is nil", "")
@@ -119,7 +412,7 @@ func ValidatePage(page *Page, isNew bool) error {
 		}
 		return aggError
 	}
-	if matched, err := regexp.Match(`^(([/])|(([/][a-zA-Z0-9-]+)+))$`, []byte(page.Url)); !matched || err != nil {
+	if !pageUrlRegexp.MatchString(page.Url) {
 		return errors.NewValidationError("invalid url", "Url")
 	}
 	return nil
@@ -0,0 +1,123 @@
+package pages
+
+import (
+	"sort"
+	"time"
+)
+
+type RecentChangeFilter struct {
+	Author           string
+	ParentID         *int
+	Since            time.Time
+	Until            time.Time // exclusive
+	IncludeProtected bool
+	Access           *PageAccess
+	Limit            int
+}
+
+type RecentChangeService struct {
+	Repository     PageChangeRepository
+	PageRepository PageRepository
+}
+
+func (s *RecentChangeService) Init() error {
+	return s.Repository.Init()
+}
+
+func (s *RecentChangeService) AddChange(page *Page, action ChangeAction, user string, comment string) error {
+	return s.Repository.AddChange(&PageChange{
+		PageID:      page.ID,
+		ParentID:    page.ParentID,
+		Action:      action,
+		Title:       page.Title,
+		Url:         page.Url,
+		Author:      user,
+		Comment:     comment,
+		ChangeDate:  time.Now(),
+		IsProtected: page.IsProtected,
+	})
+}
+
+// GetRecentChanges lists page creations, edits and deletions, newest first.
+// Protected pages are left out unless IncludeProtected is set, the same way GetAllPages does,
+// and pages the ACLs hide from the viewer when Access is set.
+func (s *RecentChangeService) GetRecentChanges(filter *RecentChangeFilter) ([]*PageChange, error) {
+	var changes []*PageChange
+	var err error
+	if filter.Author != "" {
+		changes, err = s.Repository.ListChangesByAuthor(filter.Author)
+	} else {
+		changes, err = s.Repository.ListChanges()
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID > changes[j].ID
+	})
+
+	allPages, err := s.PageRepository.GetAllPages(true)
+	if err != nil {
+		return nil, err
+	}
+	pageMap := make(map[int]*PageMeta, len(allPages))
+	for _, page := range allPages {
+		pageMap[page.ID] = page
+	}
+
+	result := make([]*PageChange, 0)
+	for _, change := range changes {
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+		if !filter.Since.IsZero() && change.ChangeDate.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !change.ChangeDate.Before(filter.Until) {
+			continue
+		}
+		current := pageMap[change.PageID]
+		if !filter.IncludeProtected && (change.IsProtected || (current != nil && current.IsProtected)) {
+			continue
+		}
+		if filter.Access != nil {
+			parentID := change.ParentID
+			if current != nil {
+				parentID = current.ParentID
+			}
+			if !filter.Access.CanView(change.PageID, parentID, change.IsProtected) {
+				continue
+			}
+		}
+		if filter.ParentID != nil && !isInSubtree(change, *filter.ParentID, pageMap) {
+			continue
+		}
+		result = append(result, change)
+	}
+	return result, nil
+}
+
+// isInSubtree checks whether the changed page is the root page or one of its descendants.
+// Deleted pages are located through the parent recorded with the change.
+func isInSubtree(change *PageChange, rootID int, pageMap map[int]*PageMeta) bool {
+	if change.PageID == rootID {
+		return true
+	}
+	parentID := change.ParentID
+	if current, ok := pageMap[change.PageID]; ok {
+		parentID = current.ParentID
+	}
+	visited := make(map[int]bool)
+	for parentID != nil && !visited[*parentID] {
+		if *parentID == rootID {
+			return true
+		}
+		visited[*parentID] = true
+		parent, ok := pageMap[*parentID]
+		if !ok {
+			return false
+		}
+		parentID = parent.ParentID
+	}
+	return false
+}
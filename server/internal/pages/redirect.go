@@ -0,0 +1,29 @@
+package pages
+
+import "time"
+
+type Redirect struct {
+	ID        int       `json:"id"`
+	FromUrl   string    `json:"fromUrl" validate:"required,max=100"`
+	ToUrl     string    `json:"toUrl" validate:"required,max=500"`
+	CreatedAt time.Time `json:"createdAt"`
+	CreatedBy string    `json:"createdBy"`
+}
+
+func (r *Redirect) GetID() int {
+	return r.ID
+}
+
+func (r *Redirect) SetID(id int) {
+	r.ID = id
+}
+
+func (r *Redirect) GetValue(field string) string {
+	switch field {
+	case "FromUrl":
+		return r.FromUrl
+	case "ToUrl":
+		return r.ToUrl
+	}
+	return ""
+}
@@ -0,0 +1,12 @@
+package pages
+
+type RedirectRepository interface {
+	Init() error
+	GetRedirectByID(id int) (*Redirect, error)
+	GetRedirectByFromUrl(url string) (*Redirect, error)
+	GetRedirectsByToUrl(url string) ([]*Redirect, error)
+	ListRedirects() ([]*Redirect, error)
+	CreateRedirect(redirect *Redirect) error
+	UpdateRedirect(redirect *Redirect) error
+	DeleteRedirect(id int) error
+}
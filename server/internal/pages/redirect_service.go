@@ -0,0 +1,197 @@
+package pages
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"wikigo/internal/common/errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const maxRedirectHops = 10
+
+var (
+	redirectFromUrlRegexp = regexp.MustCompile(`^/[A-Za-z0-9._~%/-]*$`)
+	externalUrlRegexp     = regexp.MustCompile(`^https?://[^\s]+$`)
+)
+
+type RedirectService struct {
+	Repository     RedirectRepository
+	PageRepository PageRepository
+}
+
+func (s *RedirectService) Init() error {
+	return s.Repository.Init()
+}
+
+// ResolveUrl follows the redirects starting from url and returns the final target.
+// An empty string is returned when there is no redirect for the url.
+func (s *RedirectService) ResolveUrl(url string) (string, error) {
+	target := url
+	visited := map[string]bool{url: true}
+	for i := 0; i < maxRedirectHops; i++ {
+		redirect, err := s.Repository.GetRedirectByFromUrl(target)
+		if err != nil {
+			return "", err
+		}
+		if redirect == nil {
+			break
+		}
+		if visited[redirect.ToUrl] {
+			return "", errors.NewValidationError("redirect loop detected", "url")
+		}
+		visited[redirect.ToUrl] = true
+		target = redirect.ToUrl
+	}
+	if target == url {
+		return "", nil
+	}
+	return target, nil
+}
+
+func (s *RedirectService) GetRedirects() ([]*Redirect, error) {
+	return s.Repository.ListRedirects()
+}
+
+func (s *RedirectService) GetRedirect(id int) (*Redirect, error) {
+	redirect, err := s.Repository.GetRedirectByID(id)
+	if err != nil || redirect == nil {
+		return nil, errors.NotFound("redirect not found")
+	}
+	return redirect, nil
+}
+
+// CreateRedirect adds a redirect managed by hand, such as an alias, a short link
+// or a legacy url. The target may be a page url or an external http(s) url.
+func (s *RedirectService) CreateRedirect(redirect *Redirect, user string) error {
+	if err := s.validateRedirect(redirect); err != nil {
+		return err
+	}
+	if existing, err := s.Repository.GetRedirectByFromUrl(redirect.FromUrl); err != nil {
+		return err
+	} else if existing != nil {
+		return errors.NewValidationError("a redirect already exists for this url", "FromUrl")
+	}
+	redirect.CreatedAt = time.Now()
+	redirect.CreatedBy = user
+	return s.Repository.CreateRedirect(redirect)
+}
+
+func (s *RedirectService) UpdateRedirect(redirect *Redirect) error {
+	existing, err := s.Repository.GetRedirectByID(redirect.ID)
+	if err != nil || existing == nil {
+		return errors.NotFound("redirect not found")
+	}
+	if err := s.validateRedirect(redirect); err != nil {
+		return err
+	}
+	if other, err := s.Repository.GetRedirectByFromUrl(redirect.FromUrl); err != nil {
+		return err
+	} else if other != nil && other.ID != redirect.ID {
+		return errors.NewValidationError("a redirect already exists for this url", "FromUrl")
+	}
+	existing.FromUrl = redirect.FromUrl
+	existing.ToUrl = redirect.ToUrl
+	return s.Repository.UpdateRedirect(existing)
+}
+
+func (s *RedirectService) DeleteRedirect(id int) error {
+	if _, err := s.GetRedirect(id); err != nil {
+		return err
+	}
+	return s.Repository.DeleteRedirect(id)
+}
+
+// RemoveRedirectFrom deletes the redirect of a url that is now used by a page.
+func (s *RedirectService) RemoveRedirectFrom(url string) error {
+	redirect, err := s.Repository.GetRedirectByFromUrl(url)
+	if err != nil || redirect == nil {
+		return err
+	}
+	return s.Repository.DeleteRedirect(redirect.ID)
+}
+
+func (s *RedirectService) validateRedirect(redirect *Redirect) error {
+	if err := validator.New().Struct(redirect); err != nil {
+		return errors.NewValidationError(err.Error(), "")
+	}
+	if !redirectFromUrlRegexp.MatchString(redirect.FromUrl) || strings.Contains(redirect.FromUrl, "..") {
+		return errors.NewValidationError("invalid url", "FromUrl")
+	}
+	if page, err := s.PageRepository.GetPageByUrl(redirect.FromUrl); err != nil {
+		return err
+	} else if page != nil {
+		return errors.NewValidationError("a page already exists at this url", "FromUrl")
+	}
+	if IsExternalUrl(redirect.ToUrl) {
+		return nil
+	}
+	if !pageUrlRegexp.MatchString(redirect.ToUrl) {
+		return errors.NewValidationError("target must be a page url or an http(s) url", "ToUrl")
+	}
+	if redirect.ToUrl == redirect.FromUrl {
+		return errors.NewValidationError("a url cannot redirect to itself", "ToUrl")
+	}
+	// Follow the chain from the target to make sure it does not lead back to the source.
+	target := redirect.ToUrl
+	for i := 0; i < maxRedirectHops; i++ {
+		next, err := s.Repository.GetRedirectByFromUrl(target)
+		if err != nil {
+			return err
+		}
+		if next == nil || next.ID == redirect.ID {
+			return nil
+		}
+		if next.ToUrl == redirect.FromUrl {
+			return errors.NewValidationError("redirect loop detected", "ToUrl")
+		}
+		target = next.ToUrl
+	}
+	return errors.NewValidationError("redirect chain is too long", "ToUrl")
+}
+
+// IsExternalUrl reports whether a redirect target points outside the wiki.
+func IsExternalUrl(url string) bool {
+	return externalUrlRegexp.MatchString(url)
+}
+
+// AddMoveRedirect records that a page moved from one url to another. Redirects that
+// pointed to the old url are updated to the new one so they never chain.
+func (s *RedirectService) AddMoveRedirect(fromUrl, toUrl string, user string) error {
+	if stale, err := s.Repository.GetRedirectByFromUrl(toUrl); err != nil {
+		return err
+	} else if stale != nil {
+		if err := s.Repository.DeleteRedirect(stale.ID); err != nil {
+			return err
+		}
+	}
+	previous, err := s.Repository.GetRedirectsByToUrl(fromUrl)
+	if err != nil {
+		return err
+	}
+	for _, redirect := range previous {
+		if redirect.FromUrl == toUrl {
+			continue
+		}
+		redirect.ToUrl = toUrl
+		if err := s.Repository.UpdateRedirect(redirect); err != nil {
+			return err
+		}
+	}
+	existing, err := s.Repository.GetRedirectByFromUrl(fromUrl)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		existing.ToUrl = toUrl
+		return s.Repository.UpdateRedirect(existing)
+	}
+	return s.Repository.CreateRedirect(&Redirect{
+		FromUrl:   fromUrl,
+		ToUrl:     toUrl,
+		CreatedAt: time.Now(),
+		CreatedBy: user,
+	})
+}
@@ -0,0 +1,36 @@
+package pages
+
+import (
+	"strconv"
+	"time"
+)
+
+// SearchDocument keeps the number of terms in each field of an indexed page,
+// used to normalize scores by document length, and the terms and attachments the
+// page was indexed with, so they can be removed when the page changes.
+type SearchDocument struct {
+	ID           int                 `json:"id"`
+	PageID       int                 `json:"page_id" validate:"required"`
+	FieldLengths map[SearchField]int `json:"field_lengths"`
+	Version      int                 `json:"version"`
+	Tokenizer    string              `json:"tokenizer"`
+	Terms        []string            `json:"terms,omitempty"`
+	Attachments  []string            `json:"attachments,omitempty"`
+	IndexedAt    time.Time           `json:"indexed_at"`
+}
+
+func (d *SearchDocument) GetID() int {
+	return d.ID
+}
+
+func (d *SearchDocument) SetID(id int) {
+	d.ID = id
+}
+
+func (d *SearchDocument) GetValue(field string) string {
+	switch field {
+	case "PageID":
+		return strconv.Itoa(d.PageID)
+	}
+	return ""
+}
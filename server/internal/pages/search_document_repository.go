@@ -0,0 +1,10 @@
+package pages
+
+type SearchDocumentRepository interface {
+	Init() error
+	GetSearchDocument(pageId int) (*SearchDocument, error)
+	ListSearchDocuments() ([]*SearchDocument, error)
+	SaveSearchDocument(doc *SearchDocument) error
+	DeleteSearchDocument(pageId int) error
+	DeleteAll() error
+}
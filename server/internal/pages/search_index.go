@@ -0,0 +1,156 @@
+package pages
+
+import (
+	"sync"
+	"time"
+)
+
+// SearchIndexState records which search index is active and which one is being built.
+// A build that did not finish is resumed instead of started over.
+type SearchIndexState struct {
+	Active   string `json:"active"`
+	Building string `json:"building,omitempty"`
+}
+
+// SearchIndexStore keeps named search indexes. A rebuild writes a new index next to the
+// active one and activates it when it is complete, so searches keep using the old index
+// in the meantime.
+type SearchIndexStore interface {
+	GetState() (*SearchIndexState, error)
+	SaveState(state *SearchIndexState) error
+	OpenIndex(name string) (SearchTermListRepository, SearchDocumentRepository, error)
+	DeleteIndex(name string) error
+}
+
+// searchIndex is the pair of term list and document stores making up one search index.
+// The stats of the active index are kept up to date by write and delete.
+type searchIndex struct {
+	terms SearchTermListRepository
+	docs  SearchDocumentRepository
+	stats *searchIndexStats
+}
+
+func (i *searchIndex) write(page *Page, attachments map[string]string, tokenizer Tokenizer, oldPage *Page) error {
+	oldTerms, err := i.getIndexedTerms(page.ID, oldPage, tokenizer)
+	if err != nil {
+		return err
+	}
+	postings, doc := buildSearchIndexEntry(page, attachments, tokenizer)
+	doc.IndexedAt = time.Now()
+	if err := i.terms.UpdateSearchTermLists(postings, oldTerms, page.ID); err != nil {
+		return err
+	}
+	if err := i.docs.SaveSearchDocument(doc); err != nil {
+		return err
+	}
+	if i.stats != nil {
+		i.stats.add(doc)
+	}
+	return nil
+}
+
+func (i *searchIndex) delete(pageId int, oldPage *Page, tokenizer Tokenizer) error {
+	oldTerms, err := i.getIndexedTerms(pageId, oldPage, tokenizer)
+	if err != nil {
+		return err
+	}
+	if err := i.terms.UpdateSearchTermLists(map[string]*TermPosting{}, oldTerms, pageId); err != nil {
+		return err
+	}
+	if err := i.docs.DeleteSearchDocument(pageId); err != nil {
+		return err
+	}
+	if i.stats != nil {
+		i.stats.remove(pageId)
+	}
+	return nil
+}
+
+// getIndexedTerms returns the terms a page was indexed with. Documents indexed before
+// the terms were stored fall back to the terms of the title and content of the old page.
+func (i *searchIndex) getIndexedTerms(pageId int, oldPage *Page, tokenizer Tokenizer) ([]string, error) {
+	doc, err := i.docs.GetSearchDocument(pageId)
+	if err != nil {
+		return nil, err
+	}
+	if doc != nil && doc.Terms != nil {
+		return doc.Terms, nil
+	}
+	if oldPage == nil {
+		return []string{}, nil
+	}
+	return tokenizeUnique(tokenizer, oldPage.Title+" "+oldPage.Content), nil
+}
+
+// searchIndexStats holds the document lengths needed for BM25 length normalization and
+// the attachments of each page. It is read from the documents once and then updated
+// with each write, so searches do not read every document.
+type searchIndexStats struct {
+	mu           sync.RWMutex
+	lengthSums   map[SearchField]int
+	fieldLengths map[int]map[SearchField]int
+	attachments  map[int][]string
+}
+
+func newSearchIndexStats(docs []*SearchDocument) *searchIndexStats {
+	stats := &searchIndexStats{
+		lengthSums:   make(map[SearchField]int),
+		fieldLengths: make(map[int]map[SearchField]int, len(docs)),
+		attachments:  make(map[int][]string),
+	}
+	for _, doc := range docs {
+		stats.add(doc)
+	}
+	return stats
+}
+
+func (s *searchIndexStats) add(doc *SearchDocument) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(doc.PageID)
+	s.fieldLengths[doc.PageID] = doc.FieldLengths
+	for field, length := range doc.FieldLengths {
+		s.lengthSums[field] += length
+	}
+	if len(doc.Attachments) > 0 {
+		s.attachments[doc.PageID] = doc.Attachments
+	}
+}
+
+func (s *searchIndexStats) remove(pageId int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(pageId)
+}
+
+func (s *searchIndexStats) removeLocked(pageId int) {
+	for field, length := range s.fieldLengths[pageId] {
+		s.lengthSums[field] -= length
+	}
+	delete(s.fieldLengths, pageId)
+	delete(s.attachments, pageId)
+}
+
+func (s *searchIndexStats) docCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.fieldLengths)
+}
+
+// lengthNorm returns the BM25 length normalization of a field of a page.
+func (s *searchIndexStats) lengthNorm(pageId int, field SearchField) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	lengths, ok := s.fieldLengths[pageId]
+	if !ok || s.lengthSums[field] <= 0 {
+		return 1
+	}
+	avg := float64(s.lengthSums[field]) / float64(len(s.fieldLengths))
+	return 1 - bm25B + bm25B*float64(lengths[field])/avg
+}
+
+func (s *searchIndexStats) getAttachments(pageId int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.attachments[pageId]
+}
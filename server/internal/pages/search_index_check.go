@@ -0,0 +1,214 @@
+package pages
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// SearchIndexPosting identifies the posting of a term for a page.
+type SearchIndexPosting struct {
+	Term   string `json:"term"`
+	PageID int    `json:"pageId"`
+}
+
+// SearchIndexReport lists the differences between the search index and the pages.
+type SearchIndexReport struct {
+	PageCount int `json:"pageCount"`
+	TermCount int `json:"termCount"`
+	// UnindexedPages exist but have no index document
+	UnindexedPages []int `json:"unindexedPages"`
+	// OutdatedPages changed after they were indexed, or were indexed by an older index
+	// version or another tokenizer
+	OutdatedPages []int `json:"outdatedPages"`
+	// DeletedPages are still in the index but no longer exist or are in the recycle bin
+	DeletedPages []int `json:"deletedPages"`
+	// MissingPostings are terms of an index document without a posting for the page
+	MissingPostings []*SearchIndexPosting `json:"missingPostings"`
+	// StalePostings point at a page whose index document does not have the term
+	StalePostings []*SearchIndexPosting `json:"stalePostings"`
+	Repaired      bool                  `json:"repaired"`
+	CheckedAt     time.Time             `json:"checkedAt"`
+}
+
+func (r *SearchIndexReport) IsConsistent() bool {
+	return len(r.UnindexedPages) == 0 && len(r.OutdatedPages) == 0 && len(r.DeletedPages) == 0 &&
+		len(r.MissingPostings) == 0 && len(r.StalePostings) == 0
+}
+
+// CheckSearchIndex compares the active search index with the pages. With repair, the
+// pages with a problem are indexed again and the postings of deleted pages removed,
+// which is much faster than a rebuild when only a few pages are affected.
+func (s *SearchService) CheckSearchIndex(repair bool) (*SearchIndexReport, error) {
+	index := s.index()
+	tokenizer := s.getTokenizer()
+	allPages, err := s.PageRepository.GetAllPages(true)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := index.docs.ListSearchDocuments()
+	if err != nil {
+		return nil, err
+	}
+	terms, err := index.terms.ListTerms()
+	if err != nil {
+		return nil, err
+	}
+	report := &SearchIndexReport{
+		PageCount:       len(allPages),
+		TermCount:       len(terms),
+		UnindexedPages:  make([]int, 0),
+		OutdatedPages:   make([]int, 0),
+		DeletedPages:    make([]int, 0),
+		MissingPostings: make([]*SearchIndexPosting, 0),
+		StalePostings:   make([]*SearchIndexPosting, 0),
+		CheckedAt:       time.Now(),
+	}
+
+	docMap := make(map[int]*SearchDocument, len(docs))
+	for _, doc := range docs {
+		docMap[doc.PageID] = doc
+	}
+	postedTerms := make(map[int]map[string]bool)
+	for _, term := range terms {
+		list, err := index.terms.GetSearchTermList(term)
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			continue
+		}
+		for _, posting := range list.GetPostings() {
+			if postedTerms[posting.PageID] == nil {
+				postedTerms[posting.PageID] = make(map[string]bool)
+			}
+			postedTerms[posting.PageID][term] = true
+		}
+	}
+
+	pageIds := make(map[int]bool, len(allPages))
+	for _, meta := range allPages {
+		pageIds[meta.ID] = true
+		page, err := s.PageRepository.GetPageByID(meta.ID)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			continue
+		}
+		doc := docMap[page.ID]
+		if doc == nil {
+			report.UnindexedPages = append(report.UnindexedPages, page.ID)
+			report.StalePostings = append(report.StalePostings, toSearchIndexPostings(page.ID, postedTerms[page.ID])...)
+			continue
+		}
+		if doc.Version != searchIndexVersion || doc.Tokenizer != tokenizer.Name() || doc.IndexedAt.Before(page.LastModifiedAt) {
+			report.OutdatedPages = append(report.OutdatedPages, page.ID)
+			continue
+		}
+		docTerms := make(map[string]bool, len(doc.Terms))
+		for _, term := range doc.Terms {
+			docTerms[term] = true
+			if !postedTerms[page.ID][term] {
+				report.MissingPostings = append(report.MissingPostings, &SearchIndexPosting{term, page.ID})
+			}
+		}
+		for term := range postedTerms[page.ID] {
+			if !docTerms[term] {
+				report.StalePostings = append(report.StalePostings, &SearchIndexPosting{term, page.ID})
+			}
+		}
+	}
+	for pageId := range docMap {
+		if !pageIds[pageId] {
+			report.DeletedPages = append(report.DeletedPages, pageId)
+		}
+	}
+	for pageId := range postedTerms {
+		if !pageIds[pageId] && docMap[pageId] == nil {
+			report.DeletedPages = append(report.DeletedPages, pageId)
+		}
+	}
+	sort.Ints(report.DeletedPages)
+	sort.Slice(report.StalePostings, func(i, j int) bool {
+		a, b := report.StalePostings[i], report.StalePostings[j]
+		return a.PageID < b.PageID || (a.PageID == b.PageID && a.Term < b.Term)
+	})
+
+	if repair && !report.IsConsistent() {
+		if err := s.repairSearchIndex(report, postedTerms); err != nil {
+			return nil, err
+		}
+		report.Repaired = true
+	}
+	return report, nil
+}
+
+// repairSearchIndex indexes the pages of the report again. Pages are loaded again while
+// index writes are blocked, so a page changed since the check is indexed as it is now.
+func (s *SearchService) repairSearchIndex(report *SearchIndexReport, postedTerms map[int]map[string]bool) error {
+	defer s.invalidateDictionary()
+	pageIds := make([]int, 0)
+	seen := make(map[int]bool)
+	addPage := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			pageIds = append(pageIds, id)
+		}
+	}
+	for _, ids := range [][]int{report.UnindexedPages, report.OutdatedPages, report.DeletedPages} {
+		for _, id := range ids {
+			addPage(id)
+		}
+	}
+	for _, postings := range [][]*SearchIndexPosting{report.MissingPostings, report.StalePostings} {
+		for _, posting := range postings {
+			addPage(posting.PageID)
+		}
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	index := s.index()
+	tokenizer := s.getTokenizer()
+	for _, pageId := range pageIds {
+		s.markDirty(pageId)
+		// Indexing removes the terms of the index document, other postings are removed here
+		doc, err := index.docs.GetSearchDocument(pageId)
+		if err != nil {
+			return err
+		}
+		staleTerms := make([]string, 0)
+		for term := range postedTerms[pageId] {
+			if doc == nil || !slices.Contains(doc.Terms, term) {
+				staleTerms = append(staleTerms, term)
+			}
+		}
+		if len(staleTerms) > 0 {
+			if err := index.terms.UpdateSearchTermLists(map[string]*TermPosting{}, staleTerms, pageId); err != nil {
+				return err
+			}
+		}
+		page, err := s.PageRepository.GetPageByID(pageId)
+		if err != nil {
+			return err
+		}
+		if page == nil || page.IsDeleted() {
+			err = index.delete(pageId, nil, tokenizer)
+		} else {
+			err = index.write(page, s.readAttachments(page), tokenizer, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toSearchIndexPostings(pageId int, terms map[string]bool) []*SearchIndexPosting {
+	postings := make([]*SearchIndexPosting, 0, len(terms))
+	for term := range terms {
+		postings = append(postings, &SearchIndexPosting{term, pageId})
+	}
+	return postings
+}
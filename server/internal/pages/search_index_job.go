@@ -0,0 +1,247 @@
+package pages
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+)
+
+type SearchIndexJobStatus string
+
+const (
+	SearchIndexJobIdle      SearchIndexJobStatus = "idle"
+	SearchIndexJobRunning   SearchIndexJobStatus = "running"
+	SearchIndexJobCompleted SearchIndexJobStatus = "completed"
+	SearchIndexJobFailed    SearchIndexJobStatus = "failed"
+)
+
+// SearchIndexJob reports the progress of a background search index rebuild.
+type SearchIndexJob struct {
+	Status     SearchIndexJobStatus `json:"status"`
+	Index      string               `json:"index,omitempty"`
+	Resumed    bool                 `json:"resumed"`
+	Total      int                  `json:"total"`
+	Processed  int                  `json:"processed"`
+	StartedAt  *time.Time           `json:"startedAt,omitempty"`
+	FinishedAt *time.Time           `json:"finishedAt,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// searchIndexRebuild is the index being built. Pages changed while it is built are
+// recorded and indexed again before it is activated.
+type searchIndexRebuild struct {
+	name      string
+	index     *searchIndex
+	tokenizer Tokenizer
+	dirty     map[int]bool
+}
+
+var ErrSearchIndexStoreMissing = errors.New("search index store is not configured")
+
+// StartRebuildSearchIndex rebuilds the search index in the background and returns the
+// job. A build interrupted by a restart or an error is resumed. When a rebuild is already
+// running, another one follows it, so changes made meanwhile are picked up.
+func (s *SearchService) StartRebuildSearchIndex() (*SearchIndexJob, error) {
+	if s.IndexStore == nil {
+		return nil, ErrSearchIndexStoreMissing
+	}
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	if s.job != nil && s.job.Status == SearchIndexJobRunning {
+		s.rebuildPending = true
+		job := *s.job
+		return &job, nil
+	}
+
+	state, err := s.IndexStore.GetState()
+	if err != nil {
+		return nil, err
+	}
+	name := state.Building
+	resumed := name != ""
+	if !resumed {
+		name = strconv.FormatInt(time.Now().UnixNano(), 36)
+		state.Building = name
+		if err := s.IndexStore.SaveState(state); err != nil {
+			return nil, err
+		}
+	}
+	terms, docs, err := s.IndexStore.OpenIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	rebuild := &searchIndexRebuild{
+		name:      name,
+		index:     &searchIndex{terms: terms, docs: docs},
+		tokenizer: s.getTokenizer(),
+		dirty:     make(map[int]bool),
+	}
+	s.writeMu.Lock()
+	s.rebuild = rebuild
+	s.writeMu.Unlock()
+
+	now := time.Now()
+	s.job = &SearchIndexJob{Status: SearchIndexJobRunning, Index: name, Resumed: resumed, StartedAt: &now}
+	go s.runRebuild(rebuild)
+	job := *s.job
+	return &job, nil
+}
+
+// GetSearchIndexJob returns the state of the running or last rebuild.
+func (s *SearchService) GetSearchIndexJob() *SearchIndexJob {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	if s.job == nil {
+		return &SearchIndexJob{Status: SearchIndexJobIdle}
+	}
+	job := *s.job
+	return &job
+}
+
+func (s *SearchService) runRebuild(rebuild *searchIndexRebuild) {
+	err := s.buildIndex(rebuild)
+	if err == nil {
+		err = s.activateIndex(rebuild)
+	}
+	if err != nil {
+		s.writeMu.Lock()
+		s.rebuild = nil
+		s.writeMu.Unlock()
+		log.Printf("failed to rebuild search index %s: %v\n", rebuild.name, err)
+	}
+
+	s.jobMu.Lock()
+	now := time.Now()
+	s.job.FinishedAt = &now
+	if err != nil {
+		s.job.Status = SearchIndexJobFailed
+		s.job.Error = err.Error()
+	} else {
+		s.job.Status = SearchIndexJobCompleted
+	}
+	pending := s.rebuildPending
+	s.rebuildPending = false
+	s.jobMu.Unlock()
+
+	if pending {
+		if _, err := s.StartRebuildSearchIndex(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// buildIndex indexes every page into the new index. Pages already indexed by an
+// interrupted build are skipped unless they changed since.
+func (s *SearchService) buildIndex(rebuild *searchIndexRebuild) error {
+	// Protected pages are indexed too and filtered out of the results for anonymous users
+	pages, err := s.PageRepository.GetAllPages(true)
+	if err != nil {
+		return err
+	}
+	s.updateJob(func(job *SearchIndexJob) {
+		job.Total = len(pages)
+	})
+	for i, meta := range pages {
+		page, err := s.PageRepository.GetPageByID(meta.ID)
+		if err != nil {
+			return err
+		}
+		if page != nil && !page.IsDeleted() {
+			doc, err := rebuild.index.docs.GetSearchDocument(page.ID)
+			if err != nil {
+				return err
+			}
+			if doc == nil || doc.Version != searchIndexVersion || doc.Tokenizer != rebuild.tokenizer.Name() || doc.IndexedAt.Before(page.LastModifiedAt) {
+				if err := rebuild.index.write(page, s.readAttachments(page), rebuild.tokenizer, nil); err != nil {
+					return err
+				}
+			}
+		}
+		s.updateJob(func(job *SearchIndexJob) {
+			job.Processed = i + 1
+		})
+	}
+	return nil
+}
+
+// activateIndex catches up with the pages changed during the build and swaps the new
+// index in. Index writes wait until the swap is done, so no change is lost.
+func (s *SearchService) activateIndex(rebuild *searchIndexRebuild) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	for pageId := range rebuild.dirty {
+		page, err := s.PageRepository.GetPageByID(pageId)
+		if err != nil {
+			return err
+		}
+		if page == nil || page.IsDeleted() {
+			err = rebuild.index.delete(pageId, nil, rebuild.tokenizer)
+		} else {
+			err = rebuild.index.write(page, s.readAttachments(page), rebuild.tokenizer, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Pages deleted while a build was interrupted are still in the resumed index
+	pages, err := s.PageRepository.GetAllPages(true)
+	if err != nil {
+		return err
+	}
+	pageIds := make(map[int]bool, len(pages))
+	for _, page := range pages {
+		pageIds[page.ID] = true
+	}
+	docs, err := rebuild.index.docs.ListSearchDocuments()
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if !pageIds[doc.PageID] {
+			if err := rebuild.index.delete(doc.PageID, nil, rebuild.tokenizer); err != nil {
+				return err
+			}
+		}
+	}
+
+	state, err := s.IndexStore.GetState()
+	if err != nil {
+		return err
+	}
+	previous := state.Active
+	state.Active = rebuild.name
+	state.Building = ""
+	if err := s.IndexStore.SaveState(state); err != nil {
+		return err
+	}
+	s.indexMu.Lock()
+	s.SearchTermListRepository = rebuild.index.terms
+	s.SearchDocumentRepository = rebuild.index.docs
+	s.stats = nil
+	s.indexMu.Unlock()
+	s.rebuild = nil
+	s.invalidateDictionary()
+
+	if previous != rebuild.name {
+		if err := s.IndexStore.DeleteIndex(previous); err != nil {
+			log.Printf("failed to delete search index %q: %v\n", previous, err)
+		}
+	}
+	return nil
+}
+
+func (s *SearchService) updateJob(update func(job *SearchIndexJob)) {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	if s.job != nil {
+		update(s.job)
+	}
+}
+
+// markDirty records a changed page for the rebuild in progress. The caller holds writeMu.
+func (s *SearchService) markDirty(pageId int) {
+	if s.rebuild != nil {
+		s.rebuild.dirty[pageId] = true
+	}
+}
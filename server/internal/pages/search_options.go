@@ -0,0 +1,63 @@
+package pages
+
+import (
+	"strconv"
+	"time"
+)
+
+type SearchSort string
+
+const (
+	SearchSortRelevance SearchSort = ""
+	SearchSortTitle     SearchSort = "title"
+	SearchSortModified  SearchSort = "modified"
+)
+
+// queryFieldParentID filters on a subtree by page ID. It is set through SearchOptions
+// and cannot be written in a query.
+const queryFieldParentID = "parentid"
+
+// SearchOptions narrows and pages the results of a search. Filters are combined with the
+// filters written in the query; a page has to have all the tags. Protected pages are
+// only returned with IncludeProtected, which is set for logged in users, and pages
+// restricted by an ACL only when Access allows the user to view them.
+type SearchOptions struct {
+	IncludeProtected bool
+	Access           *PageAccess
+	Offset           int
+	Limit            int
+	Tags             []string
+	ParentID         *int
+	Author           string
+	ModifiedSince    time.Time
+	ModifiedUntil    time.Time // exclusive
+	Sort             SearchSort
+}
+
+type SearchResults struct {
+	Results []*SearchResult `json:"results"`
+	Total   int             `json:"total"`
+}
+
+func (o *SearchOptions) addFilters(query *SearchQuery) {
+	addFilter := func(field string, value string) {
+		query.Groups = append(query.Groups, []*QueryClause{{Occur: QueryMust, Field: field, Value: value}})
+	}
+	for _, tag := range o.Tags {
+		if tag != "" {
+			addFilter(QueryFieldTag, tag)
+		}
+	}
+	if o.Author != "" {
+		addFilter(QueryFieldAuthor, o.Author)
+	}
+	if o.ParentID != nil {
+		addFilter(queryFieldParentID, strconv.Itoa(*o.ParentID))
+	}
+}
+
+// HasFilters reports whether the options alone select pages, so a search without
+// query text lists the filtered pages.
+func (o *SearchOptions) HasFilters() bool {
+	return len(o.Tags) > 0 || o.Author != "" || o.ParentID != nil || !o.ModifiedSince.IsZero() || !o.ModifiedUntil.IsZero()
+}
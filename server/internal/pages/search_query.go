@@ -0,0 +1,296 @@
+package pages
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+type QueryOccur int
+
+const (
+	QueryShould QueryOccur = iota
+	QueryMust
+	QueryMustNot
+)
+
+// Query fields. Text fields restrict where terms match, the others filter on page properties.
+const (
+	QueryFieldTitle  = "title"
+	QueryFieldTag    = "tag"
+	QueryFieldAuthor = "author"
+	QueryFieldUnder  = "under"
+)
+
+var queryFields = map[string]bool{
+	QueryFieldTitle:  true,
+	QueryFieldTag:    true,
+	QueryFieldAuthor: true,
+	QueryFieldUnder:  true,
+}
+
+// QueryClause is a term, a quoted phrase or a field filter of a search query.
+type QueryClause struct {
+	Occur  QueryOccur
+	Field  string
+	Value  string
+	Terms  []string
+	Phrase bool
+}
+
+// IsTextClause reports whether the clause is matched against the search index
+// rather than against page properties.
+func (c *QueryClause) IsTextClause() bool {
+	return c.Field == "" || c.Field == QueryFieldTitle
+}
+
+// SearchQuery is a parsed query. Each group holds one clause, or several clauses joined
+// by OR of which at least one has to match.
+type SearchQuery struct {
+	Groups [][]*QueryClause
+}
+
+// ParseSearchQuery parses the search syntax:
+//
+//	deploy guide         pages matching any of the terms, best matches first
+//	"connection refused" the exact phrase
+//	+docker -windows     a required and an excluded term or phrase
+//	linux OR macos       at least one of the terms
+//	title:install        the term or phrase in the title
+//	tag:go author:alice  pages with the tag, or created or last edited by the user
+//	under:/guides        the page at the url and its descendants
+//
+// Terms are split with the same tokenizer as the indexed pages.
+func ParseSearchQuery(text string, tokenizer Tokenizer) *SearchQuery {
+	query := &SearchQuery{}
+	runes := []rune(text)
+	joinNext := false
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		clause := &QueryClause{}
+		if runes[i] == '+' || runes[i] == '-' {
+			if runes[i] == '+' {
+				clause.Occur = QueryMust
+			} else {
+				clause.Occur = QueryMustNot
+			}
+			i++
+		}
+		if field, next, ok := readQueryField(runes, i); ok {
+			clause.Field = field
+			i = next
+			if clause.Occur == QueryShould {
+				clause.Occur = QueryMust
+			}
+		}
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			clause.Value = string(runes[i+1 : end])
+			clause.Phrase = true
+			if clause.Occur == QueryShould {
+				clause.Occur = QueryMust
+			}
+			i = min(end+1, len(runes))
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			clause.Value = string(runes[i:end])
+			i = end
+		}
+
+		if clause.Value == "OR" && clause.Occur == QueryShould && clause.Field == "" && !clause.Phrase {
+			joinNext = len(query.Groups) > 0
+			continue
+		}
+		if clause.IsTextClause() {
+			clause.Terms = tokenizeAll(tokenizer, clause.Value)
+			if !clause.Phrase {
+				clause.Terms = tokenizeUnique(tokenizer, clause.Value)
+			}
+			if len(clause.Terms) == 0 {
+				continue
+			}
+		} else if clause.Value == "" {
+			continue
+		}
+
+		if joinNext {
+			last := len(query.Groups) - 1
+			query.Groups[last] = append(query.Groups[last], clause)
+		} else {
+			query.Groups = append(query.Groups, []*QueryClause{clause})
+		}
+		joinNext = false
+	}
+	return query
+}
+
+func readQueryField(runes []rune, start int) (string, int, bool) {
+	end := start
+	for end < len(runes) && unicode.IsLetter(runes[end]) {
+		end++
+	}
+	if end >= len(runes)-1 || runes[end] != ':' || unicode.IsSpace(runes[end+1]) {
+		return "", start, false
+	}
+	field := strings.ToLower(string(runes[start:end]))
+	if !queryFields[field] {
+		return "", start, false
+	}
+	return field, end + 1, true
+}
+
+// Terms returns the distinct index terms that can contribute to a match.
+func (q *SearchQuery) Terms() []string {
+	seen := make(map[string]bool)
+	terms := make([]string, 0)
+	for _, group := range q.Groups {
+		for _, clause := range group {
+			for _, term := range clause.Terms {
+				if !seen[term] {
+					seen[term] = true
+					terms = append(terms, term)
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// ExpandableTerms returns the terms that may match indexed terms by prefix or by
+// a small edit distance. Terms of phrases and excluded terms only match exactly.
+func (q *SearchQuery) ExpandableTerms() []string {
+	exact := make(map[string]bool)
+	for _, group := range q.Groups {
+		for _, clause := range group {
+			if clause.Phrase || clause.Occur == QueryMustNot {
+				for _, term := range clause.Terms {
+					exact[term] = true
+				}
+			}
+		}
+	}
+	terms := make([]string, 0)
+	for _, term := range q.Terms() {
+		if !exact[term] {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// NeedsAllPages reports whether matching pages can be found without the index, either
+// because the query only filters pages or because a term is joined to a filter by OR.
+func (q *SearchQuery) NeedsAllPages() bool {
+	positive := false
+	for _, group := range q.Groups {
+		for _, clause := range group {
+			if clause.IsTextClause() && clause.Occur != QueryMustNot {
+				positive = true
+			}
+		}
+		if len(group) > 1 && !isTextGroup(group) {
+			return true
+		}
+	}
+	return !positive
+}
+
+// HasRequiredClause reports whether any group has to match. When there is none,
+// at least one optional clause has to match.
+func (q *SearchQuery) HasRequiredClause() bool {
+	for _, group := range q.Groups {
+		if len(group) > 1 || group[0].Occur != QueryShould {
+			return true
+		}
+	}
+	return false
+}
+
+// termPostings holds the postings of the query terms by term and page ID.
+type termPostings map[string]map[int]*TermPosting
+
+// matchesText checks a text clause against the index: every term has to occur in the page,
+// and for a phrase at consecutive positions of the same field.
+func (c *QueryClause) matchesText(pageId int, postings termPostings) bool {
+	var fields []SearchField
+	if c.Field == QueryFieldTitle {
+		fields = []SearchField{SearchFieldTitle}
+	}
+	for _, term := range c.Terms {
+		posting := postings[term][pageId]
+		if posting == nil || !hasTermInFields(posting, fields) {
+			return false
+		}
+	}
+	if !c.Phrase || len(c.Terms) < 2 {
+		return true
+	}
+	first := postings[c.Terms[0]][pageId]
+	if first.Positions == nil {
+		return true // indexed without positions
+	}
+	for field, starts := range first.Positions {
+		if fields != nil && !slices.Contains(fields, field) {
+			continue
+		}
+		for _, start := range starts {
+			if phraseAt(c.Terms, pageId, postings, field, start) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasTermInFields(posting *TermPosting, fields []SearchField) bool {
+	if fields == nil {
+		return true
+	}
+	for _, field := range fields {
+		if posting.Frequencies[field] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func phraseAt(terms []string, pageId int, postings termPostings, field SearchField, start int) bool {
+	for i := 1; i < len(terms); i++ {
+		if _, found := slices.BinarySearch(postings[terms[i]][pageId].Positions[field], start+i); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// isGroupSatisfied evaluates a group with the given clause matcher. A single optional clause
+// never excludes a page, it only contributes to the score.
+func isGroupSatisfied(group []*QueryClause, matches func(*QueryClause) bool) bool {
+	if len(group) == 1 && group[0].Occur == QueryShould {
+		return true
+	}
+	for _, clause := range group {
+		if matches(clause) != (clause.Occur == QueryMustNot) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTextGroup(group []*QueryClause) bool {
+	for _, clause := range group {
+		if !clause.IsTextClause() {
+			return false
+		}
+	}
+	return true
+}
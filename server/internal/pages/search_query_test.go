@@ -0,0 +1,162 @@
+package pages
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	query := ParseSearchQuery(`deploy +docker -windows "connection refused" linux OR macos title:install tag:go under:/guides -author:bob`, DefaultTokenizer{})
+	type clause struct {
+		Occur  QueryOccur
+		Field  string
+		Value  string
+		Terms  []string
+		Phrase bool
+	}
+	expected := [][]clause{
+		{{QueryShould, "", "deploy", []string{"deploy"}, false}},
+		{{QueryMust, "", "docker", []string{"docker"}, false}},
+		{{QueryMustNot, "", "windows", []string{"windows"}, false}},
+		{{QueryMust, "", "connection refused", []string{"connection", "refused"}, true}},
+		{{QueryShould, "", "linux", []string{"linux"}, false}, {QueryShould, "", "macos", []string{"macos"}, false}},
+		{{QueryMust, "title", "install", []string{"install"}, false}},
+		{{QueryMust, "tag", "go", nil, false}},
+		{{QueryMust, "under", "/guides", nil, false}},
+		{{QueryMustNot, "author", "bob", nil, false}},
+	}
+	if len(query.Groups) != len(expected) {
+		t.Fatalf("got %d groups, expected %d", len(query.Groups), len(expected))
+	}
+	for i, group := range query.Groups {
+		got := make([]clause, len(group))
+		for j, c := range group {
+			got[j] = clause{c.Occur, c.Field, c.Value, c.Terms, c.Phrase}
+		}
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("group %d = %+v, expected %+v", i, got, expected[i])
+		}
+	}
+}
+
+func TestParseSearchQueryIgnoresEmptyClauses(t *testing.T) {
+	query := ParseSearchQuery(`OR the "" tag: -`, DefaultTokenizer{})
+	if len(query.Groups) != 1 || query.Groups[0][0].Value != "tag:" {
+		t.Errorf("unexpected groups %+v", query.Groups)
+	}
+}
+
+func TestMatchesTextPhrase(t *testing.T) {
+	postings := make(termPostings)
+	add := func(page *Page) {
+		entry, _ := buildSearchIndexEntry(page, nil, DefaultTokenizer{})
+		for term, posting := range entry {
+			if postings[term] == nil {
+				postings[term] = make(map[int]*TermPosting)
+			}
+			postings[term][page.ID] = posting
+		}
+	}
+	add(&Page{ID: 1, Title: "Errors", Content: "<p>dial tcp: connection refused by server</p>"})
+	add(&Page{ID: 2, Title: "Network", Content: "<p>the server refused the connection</p>"})
+	add(&Page{ID: 3, Title: "Connection refused", Content: "<p>Troubleshooting</p>"})
+
+	phrase := ParseSearchQuery(`"connection refused"`, DefaultTokenizer{}).Groups[0][0]
+	titlePhrase := ParseSearchQuery(`title:"connection refused"`, DefaultTokenizer{}).Groups[0][0]
+	tests := []struct {
+		clause   *QueryClause
+		pageId   int
+		expected bool
+	}{
+		{phrase, 1, true},
+		{phrase, 2, false},
+		{phrase, 3, true},
+		{titlePhrase, 1, false},
+		{titlePhrase, 3, true},
+	}
+	for _, tt := range tests {
+		if got := tt.clause.matchesText(tt.pageId, postings); got != tt.expected {
+			t.Errorf("%s:%q matchesText(%d) = %v, expected %v", tt.clause.Field, tt.clause.Value, tt.pageId, got, tt.expected)
+		}
+	}
+}
+
+func TestSetPostingUpdatesReorderedWords(t *testing.T) {
+	lists := make(map[string]*SearchTermList)
+	index := func(page *Page) {
+		entry, _ := buildSearchIndexEntry(page, nil, DefaultTokenizer{})
+		for term, posting := range entry {
+			if lists[term] == nil {
+				lists[term] = &SearchTermList{Term: term}
+			}
+			lists[term].SetPosting(posting)
+		}
+	}
+	index(&Page{ID: 1, Title: "Errors", Content: "<p>connection refused by server</p>"})
+	before := make(map[string]*TermPosting, len(lists))
+	for term, list := range lists {
+		before[term] = list.Postings[0]
+	}
+	index(&Page{ID: 1, Title: "Errors", Content: "<p>server refused connection</p>"})
+	if lists["server"].Postings[0] == before["server"] {
+		t.Error("SetPosting() kept the posting of a reordered word")
+	}
+
+	postings := make(termPostings)
+	for term, list := range lists {
+		postings[term] = map[int]*TermPosting{1: list.Postings[0]}
+	}
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{`"connection refused"`, false},
+		{`"refused connection"`, true},
+	}
+	for _, tt := range tests {
+		clause := ParseSearchQuery(tt.query, DefaultTokenizer{}).Groups[0][0]
+		if got := clause.matchesText(1, postings); got != tt.expected {
+			t.Errorf("%s matchesText() = %v, expected %v", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestIsGroupSatisfied(t *testing.T) {
+	matched := map[string]bool{"linux": true}
+	matches := func(c *QueryClause) bool { return matched[c.Value] }
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"windows", true},
+		{"+windows", false},
+		{"+linux", true},
+		{"-linux", false},
+		{"-windows", true},
+		{"windows OR linux", true},
+		{"windows OR macos", false},
+	}
+	for _, tt := range tests {
+		group := ParseSearchQuery(tt.query, DefaultTokenizer{}).Groups[0]
+		if got := isGroupSatisfied(group, matches); got != tt.expected {
+			t.Errorf("isGroupSatisfied(%q) = %v, expected %v", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestSearchOptionsAddFilters(t *testing.T) {
+	parentID := 7
+	options := &SearchOptions{Tags: []string{"go", ""}, Author: "alice", ParentID: &parentID}
+	query := ParseSearchQuery("deploy parent:3", DefaultTokenizer{})
+	options.addFilters(query)
+	var filters []string
+	for _, group := range query.Groups {
+		if len(group) == 1 && group[0].Occur == QueryMust && group[0].Field != "" {
+			filters = append(filters, group[0].Field+"="+group[0].Value)
+		}
+	}
+	expected := []string{"tag=go", "author=alice", "parentid=7"}
+	if !reflect.DeepEqual(filters, expected) {
+		t.Errorf("filters = %v, expected %v", filters, expected)
+	}
+}
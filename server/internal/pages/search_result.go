@@ -0,0 +1,111 @@
+package pages
+
+import (
+	"html"
+	"strings"
+)
+
+const (
+	snippetLength  = 200
+	snippetContext = 40
+)
+
+type SearchResult struct {
+	ID        int     `json:"id"`
+	Title     string  `json:"title"`
+	Url       string  `json:"url"`
+	ShortDesc string  `json:"shortDesc"`
+	Score     float64 `json:"score"`
+	// Snippet is an HTML escaped plain-text excerpt in which the matched terms are
+	// wrapped in <mark> tags.
+	Snippet string `json:"snippet"`
+	// Attachments are the media URLs of the files attached to the page when their
+	// text matched the query.
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+func newSearchResult(page *Page, score float64, terms []string, tokenizer Tokenizer) *SearchResult {
+	return &SearchResult{
+		ID:        page.ID,
+		Title:     page.Title,
+		Url:       page.Url,
+		ShortDesc: page.ShortDesc,
+		Score:     score,
+		Snippet:   BuildSnippet(page.Content, terms, tokenizer),
+	}
+}
+
+// HtmlToPlainText removes the tags of html content, decodes entities and collapses whitespace.
+func HtmlToPlainText(content string) string {
+	plain := html.UnescapeString(htmlTagRegexp.ReplaceAllString(content, " "))
+	return strings.Join(strings.Fields(plain), " ")
+}
+
+// BuildSnippet returns an excerpt of the content around the part with the most distinct
+// matching terms. Without any match the excerpt is the beginning of the content.
+func BuildSnippet(content string, terms []string, tokenizer Tokenizer) string {
+	runes := []rune(HtmlToPlainText(content))
+	termSet := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		termSet[term] = true
+	}
+	matches := make([]Token, 0)
+	for _, span := range tokenizer.Tokenize(runes) {
+		if termSet[span.Term] {
+			matches = append(matches, span)
+		}
+	}
+
+	bestStart, bestDistinct, bestCount := 0, 0, 0
+	for i, first := range matches {
+		distinct := make(map[string]bool)
+		count := 0
+		for _, span := range matches[i:] {
+			if span.End > first.Start+snippetLength-snippetContext {
+				break
+			}
+			distinct[span.Term] = true
+			count++
+		}
+		if len(distinct) > bestDistinct || (len(distinct) == bestDistinct && count > bestCount) {
+			bestStart, bestDistinct, bestCount = first.Start, len(distinct), count
+		}
+	}
+
+	// Cut at word boundaries, keeping some context before the first match
+	start := max(0, bestStart-snippetContext)
+	for i := start; start > 0 && i < bestStart; i++ {
+		if runes[i] == ' ' {
+			start = i + 1
+			break
+		}
+	}
+	end := min(len(runes), start+snippetLength)
+	for i := end; end < len(runes) && i > bestStart; i-- {
+		if runes[i] == ' ' {
+			end = i
+			break
+		}
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	pos := start
+	for _, span := range matches {
+		if span.Start < pos || span.End > end {
+			continue
+		}
+		sb.WriteString(html.EscapeString(string(runes[pos:span.Start])))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(string(runes[span.Start:span.End])))
+		sb.WriteString("</mark>")
+		pos = span.End
+	}
+	sb.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
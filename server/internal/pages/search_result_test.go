@@ -0,0 +1,55 @@
+package pages
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildSnippet(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		terms    []string
+		expected string
+	}{
+		{
+			name:     "Matches are marked and html is escaped",
+			content:  "<p>Use <code>a &lt; b</code> to compare, then deploy</p>",
+			terms:    []string{"compare", "deploy"},
+			expected: "Use a &lt; b to <mark>compare</mark>, then <mark>deploy</mark>",
+		},
+		{
+			name:     "No match returns the beginning",
+			content:  "<p>Short page</p>",
+			terms:    []string{"missing"},
+			expected: "Short page",
+		},
+		{
+			name:     "Chinese bigrams",
+			content:  "<p>这是测试内容</p>",
+			terms:    []string{"测试"},
+			expected: "这是<mark>测试</mark>内容",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildSnippet(tt.content, tt.terms, DefaultTokenizer{}); got != tt.expected {
+				t.Errorf("BuildSnippet() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildSnippetLongContent(t *testing.T) {
+	content := "<p>" + strings.Repeat("intro text ", 50) + "the deploy step needs docker installed " + strings.Repeat("more text ", 50) + "</p>"
+	got := BuildSnippet(content, []string{"deploy", "docker"}, DefaultTokenizer{})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("expected ellipses around the excerpt, got %q", got)
+	}
+	if !strings.Contains(got, "<mark>deploy</mark> step needs <mark>docker</mark>") {
+		t.Errorf("expected the matches in the excerpt, got %q", got)
+	}
+	if n := len([]rune(got)); n > snippetLength+40 {
+		t.Errorf("excerpt is too long: %d runes", n)
+	}
+}
@@ -1,20 +1,76 @@
 package pages
 
 import (
-	"regexp"
+	"log"
+	"math"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
-	"unicode"
+	"sync"
+	"sync/atomic"
+
+	"wikigo/internal/filemanager"
+)
+
+// BM25 parameters. Each field is normalized by its own average length and
+// weighted so title matches outrank body matches.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// searchIndexVersion is stored with each indexed page. Indexes written by an older
+// version are rebuilt at startup.
+const searchIndexVersion = 5
+
+var searchFieldWeights = map[SearchField]float64{
+	SearchFieldTitle:       3,
+	SearchFieldTags:        2,
+	SearchFieldDescription: 1.5,
+	SearchFieldBody:        1,
+	SearchFieldAttachment:  0.5,
+}
+
+// Query terms missing from the index are expanded to indexed terms they prefix, or
+// else to terms within a small edit distance. Expanded matches score lower.
+const (
+	maxTermExpansions   = 20
+	prefixMatchWeight   = 0.8
+	fuzzyMatchWeight    = 0.6
+	maxSuggestions      = 10
+	minSuggestionLength = 2
 )
 
 type SearchService struct {
+	// SearchTermListRepository and SearchDocumentRepository are the active index. They are
+	// replaced when a rebuild completes, so they are read through index().
 	SearchTermListRepository SearchTermListRepository
+	SearchDocumentRepository SearchDocumentRepository
 	PageRepository           PageRepository
+	IndexStore               SearchIndexStore
+	// FileManager reads attachments when attachment indexing is enabled
+	FileManager filemanager.FileManager
+
+	indexMu        sync.RWMutex
+	stats          *searchIndexStats
+	writeMu        sync.Mutex
+	rebuild        *searchIndexRebuild
+	job            *SearchIndexJob
+	jobMu          sync.Mutex
+	rebuildPending bool
+
+	indexAttachments atomic.Bool
+	dictionary       *TermDictionary
+	dictionaryMu     sync.Mutex
+	tokenizer        Tokenizer
+	tokenizerMu      sync.RWMutex
 }
 
-func NewSearchService(searchTermListRepository SearchTermListRepository, pageRepository PageRepository) *SearchService {
+func NewSearchService(searchTermListRepository SearchTermListRepository, searchDocumentRepository SearchDocumentRepository, pageRepository PageRepository) *SearchService {
 	return &SearchService{
 		SearchTermListRepository: searchTermListRepository,
+		SearchDocumentRepository: searchDocumentRepository,
 		PageRepository:           pageRepository,
 	}
 }
@@ -23,175 +79,771 @@ func (s *SearchService) Init() error {
 	if err := s.SearchTermListRepository.Init(); err != nil {
 		return err
 	}
+	if err := s.SearchDocumentRepository.Init(); err != nil {
+		return err
+	}
 	if err := s.PageRepository.Init(); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *SearchService) Search(text string, pageSize int) ([]*PageMeta, error) {
-	terms := Tokenize(text)
-	pageMatchCount := make(map[int]int)
-	for _, term := range terms {
-		if term == "" {
-			continue
-		}
-		searchTermList, err := s.SearchTermListRepository.GetSearchTermList(term)
-		if err != nil {
+// index returns the active search index.
+func (s *SearchService) index() *searchIndex {
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	return &searchIndex{terms: s.SearchTermListRepository, docs: s.SearchDocumentRepository, stats: s.stats}
+}
+
+// getIndexStats returns the stats of the active index. They are read from the index
+// documents after startup and after a rebuild, while index writes are blocked.
+func (s *SearchService) getIndexStats() (*searchIndexStats, error) {
+	if stats := s.index().stats; stats != nil {
+		return stats, nil
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	index := s.index()
+	if index.stats != nil {
+		return index.stats, nil
+	}
+	docs, err := index.docs.ListSearchDocuments()
+	if err != nil {
+		return nil, err
+	}
+	stats := newSearchIndexStats(docs)
+	s.indexMu.Lock()
+	if s.SearchDocumentRepository == index.docs {
+		s.stats = stats
+	}
+	s.indexMu.Unlock()
+	return stats, nil
+}
+
+// Search ranks the pages matching a query with BM25 and returns a page of results with
+// an excerpt around the matches. See ParseSearchQuery for the syntax.
+func (s *SearchService) Search(text string, options *SearchOptions) (*SearchResults, error) {
+	if options == nil {
+		options = &SearchOptions{}
+	}
+	tokenizer := s.getTokenizer()
+	query := ParseSearchQuery(text, tokenizer)
+	options.addFilters(query)
+	if len(query.Groups) == 0 && !options.HasFilters() {
+		return &SearchResults{Results: []*SearchResult{}}, nil
+	}
+	stats, err := s.getIndexStats()
+	if err != nil {
+		return nil, err
+	}
+	postings := make(termPostings)
+	for _, term := range query.Terms() {
+		if err := s.loadPostings(postings, term, term); err != nil {
 			return nil, err
 		}
-		if searchTermList == nil {
-			searchTermList = &SearchTermList{Term: term, PageIds: []int{}}
-		}
-		pageIds := searchTermList.PageIds
-		for _, pageId := range pageIds {
-			pageMatchCount[pageId]++
+	}
+	termWeights, expansions, err := s.expandTerms(query, postings)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := s.newPageFilter()
+	if err != nil {
+		return nil, err
+	}
+	candidates := getCandidatePages(query, postings, filter.pageMap)
+	scoredTerms := make(map[string]bool)
+	for _, group := range query.Groups {
+		for _, clause := range group {
+			if clause.IsTextClause() && clause.Occur != QueryMustNot {
+				for _, term := range clause.Terms {
+					scoredTerms[term] = true
+				}
+			}
 		}
 	}
-	// Sort page IDs by match count descending
-	type pageCount struct {
+
+	type pageScore struct {
 		pageId int
-		count  int
+		score  float64
 	}
-	var pageCounts []pageCount
-	for pageId, count := range pageMatchCount {
-		pageCounts = append(pageCounts, pageCount{pageId, count})
+	docCount := stats.docCount()
+	pageScores := make([]pageScore, 0, len(candidates))
+	for _, pageId := range candidates {
+		matchesText := func(clause *QueryClause) bool {
+			return clause.matchesText(pageId, postings)
+		}
+		satisfied := true
+		for _, group := range query.Groups {
+			if isTextGroup(group) && !isGroupSatisfied(group, matchesText) {
+				satisfied = false
+				break
+			}
+		}
+		if !satisfied {
+			continue
+		}
+		score := 0.0
+		for term := range scoredTerms {
+			if posting := postings[term][pageId]; posting != nil {
+				weight := 1.0
+				if w, ok := termWeights[term]; ok {
+					weight = w
+				}
+				score += weight * bm25Idf(max(docCount, len(postings[term])), len(postings[term])) * bm25TermScore(posting, stats)
+			}
+		}
+		pageScores = append(pageScores, pageScore{pageId, score})
 	}
-	// Sort descending by count
-	sort.Slice(pageCounts, func(i, j int) bool {
-		return pageCounts[i].count > pageCounts[j].count
+	sort.Slice(pageScores, func(i, j int) bool {
+		if pageScores[i].score != pageScores[j].score {
+			return pageScores[i].score > pageScores[j].score
+		}
+		return pageScores[i].pageId < pageScores[j].pageId
 	})
 
-	results := make([]*PageMeta, 0)
-	for _, pc := range pageCounts {
-		if pc.count > 0 {
-			if pageSize > 0 && len(results) >= pageSize {
-				break
-			}
-			page, err := s.PageRepository.GetPageByID(pc.pageId)
-			if err != nil {
+	highlightTerms := make([]string, 0, len(scoredTerms)+len(expansions))
+	for term := range scoredTerms {
+		highlightTerms = append(highlightTerms, term)
+	}
+	highlightTerms = append(highlightTerms, expansions...)
+	matched := make([]*searchCandidate, 0)
+	for _, ps := range pageScores {
+		meta := filter.pageMap[ps.pageId]
+		if meta == nil {
+			continue
+		}
+		candidate := &searchCandidate{meta: meta, score: ps.score}
+		if ok, err := filter.matchesPage(query, options, candidate, postings); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		matched = append(matched, candidate)
+	}
+
+	switch options.Sort {
+	case SearchSortTitle:
+		sort.SliceStable(matched, func(i, j int) bool {
+			return strings.ToLower(matched[i].meta.Title) < strings.ToLower(matched[j].meta.Title)
+		})
+	case SearchSortModified:
+		loaded := matched[:0]
+		for _, candidate := range matched {
+			if page, err := filter.getPage(candidate); err != nil {
 				return nil, err
+			} else if page != nil {
+				loaded = append(loaded, candidate)
+			}
+		}
+		matched = loaded
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].page.LastModifiedAt.After(matched[j].page.LastModifiedAt)
+		})
+	}
+
+	results := &SearchResults{Results: make([]*SearchResult, 0), Total: len(matched)}
+	for i := options.Offset; i < len(matched); i++ {
+		if options.Limit > 0 && len(results.Results) >= options.Limit {
+			break
+		}
+		page, err := filter.getPage(matched[i])
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			continue
+		}
+		result := newSearchResult(page, matched[i].score, highlightTerms, tokenizer)
+		for term := range scoredTerms {
+			if posting := postings[term][result.ID]; posting != nil && posting.Frequencies[SearchFieldAttachment] > 0 {
+				result.Attachments = stats.getAttachments(result.ID)
+				break
 			}
-			results = append(results, &PageMeta{
-				ID:    page.ID,
-				Title: page.Title,
-				Url:   page.Url,
-			})
 		}
+		results.Results = append(results.Results, result)
 	}
 	return results, nil
 }
 
-func (s *SearchService) AddPageSearchTerms(page *Page) error {
-	if page == nil {
-		return nil // No valid page to add
+// loadPostings reads the postings of an indexed term into postings[key]. Postings of
+// several terms loaded under the same key are merged per page.
+func (s *SearchService) loadPostings(postings termPostings, key string, term string) error {
+	searchTermList, err := s.index().terms.GetSearchTermList(term)
+	if err != nil || searchTermList == nil {
+		return err
+	}
+	if postings[key] == nil {
+		postings[key] = make(map[int]*TermPosting)
+	}
+	for _, posting := range searchTermList.GetPostings() {
+		if key == term {
+			postings[key][posting.PageID] = posting
+			continue
+		}
+		merged := postings[key][posting.PageID]
+		if merged == nil {
+			merged = &TermPosting{PageID: posting.PageID, Frequencies: make(map[SearchField]int)}
+			postings[key][posting.PageID] = merged
+		}
+		for field, freq := range posting.Frequencies {
+			merged.Frequencies[field] += freq
+		}
 	}
-	terms := Tokenize(page.Title + " " + page.Content)
-	return s.SearchTermListRepository.UpdateSearchTermLists(terms, []string{}, page.ID)
+	return nil
 }
 
-func (s *SearchService) UpdatePageSearchTerms(page *Page, oldPage *Page) error {
-	if page == nil || page.ID <= 0 {
-		return nil // No valid page to update
+// expandTerms loads the postings of prefix or fuzzy matches for the query terms that are
+// not indexed. It returns the score weight of each expanded query term and the matched terms.
+func (s *SearchService) expandTerms(query *SearchQuery, postings termPostings) (map[string]float64, []string, error) {
+	weights := make(map[string]float64)
+	expansions := make([]string, 0)
+	var dictionary *TermDictionary
+	for _, term := range query.ExpandableTerms() {
+		if len(postings[term]) > 0 {
+			continue
+		}
+		if dictionary == nil {
+			var err error
+			if dictionary, err = s.getDictionary(); err != nil {
+				return nil, nil, err
+			}
+		}
+		matches := dictionary.PrefixMatches(term, maxTermExpansions)
+		weights[term] = prefixMatchWeight
+		if len(matches) == 0 {
+			matches = dictionary.FuzzyMatches(term, maxTermExpansions)
+			weights[term] = fuzzyMatchWeight
+		}
+		for _, match := range matches {
+			if err := s.loadPostings(postings, term, match); err != nil {
+				return nil, nil, err
+			}
+		}
+		expansions = append(expansions, matches...)
 	}
-	var oldTerms []string
-	if oldPage != nil {
-		oldTerms = Tokenize(oldPage.Title + " " + oldPage.Content)
-	} else {
-		oldTerms = []string{}
+	return weights, expansions, nil
+}
+
+func (s *SearchService) getDictionary() (*TermDictionary, error) {
+	s.dictionaryMu.Lock()
+	defer s.dictionaryMu.Unlock()
+	if s.dictionary == nil {
+		terms, err := s.index().terms.ListTerms()
+		if err != nil {
+			return nil, err
+		}
+		s.dictionary = NewTermDictionary(terms)
 	}
-	terms := Tokenize(page.Title + " " + page.Content)
-	return s.SearchTermListRepository.UpdateSearchTermLists(terms, oldTerms, page.ID)
+	return s.dictionary, nil
 }
 
-func (s *SearchService) DeletePageSearchTerms(page *Page) error {
-	oldTerms := Tokenize(page.Title + " " + page.Content)
-	return s.SearchTermListRepository.UpdateSearchTermLists([]string{}, oldTerms, page.ID)
+// SetLanguage selects the tokenizer for the site language. The index has to be
+// rebuilt afterwards, see RebuildSearchIndexIfOutdated.
+func (s *SearchService) SetLanguage(language string) {
+	s.tokenizerMu.Lock()
+	s.tokenizer = NewTokenizer(language)
+	s.tokenizerMu.Unlock()
 }
 
-func Tokenize(text string) []string {
-	// Remove HTML tags
-	re := regexp.MustCompile(`<[^>]*>`) // matches anything between < and >
-	plain := re.ReplaceAllString(text, " ")
-
-	// Common English stopwords
-	stopwords := map[string]bool{
-		"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
-		"for": true, "from": true, "has": true, "he": true, "in": true, "is": true, "it": true, "its": true,
-		"of": true, "on": true, "that": true, "the": true, "to": true, "was": true, "will": true, "with": true,
-	}
-
-	// Use a map to track unique terms
-	uniqueTerms := make(map[string]bool)
-	runes := []rune(plain)
-
-	// Process text character by character, handling different scripts separately
-	for i := 0; i < len(runes); i++ {
-		if isChinese(runes[i]) {
-			// Find consecutive Chinese characters
-			start := i
-			for i < len(runes) && isChinese(runes[i]) {
-				i++
+func (s *SearchService) getTokenizer() Tokenizer {
+	s.tokenizerMu.RLock()
+	defer s.tokenizerMu.RUnlock()
+	if s.tokenizer == nil {
+		return DefaultTokenizer{}
+	}
+	return s.tokenizer
+}
+
+// invalidateDictionary drops the cached dictionary after the index changed.
+func (s *SearchService) invalidateDictionary() {
+	s.dictionaryMu.Lock()
+	s.dictionary = nil
+	s.dictionaryMu.Unlock()
+}
+
+// SearchSuggestions completes the query as the user types.
+type SearchSuggestions struct {
+	Pages []*PageMeta `json:"pages"`
+	Terms []string    `json:"terms"`
+}
+
+// Suggest returns pages whose title starts with the text or has a word starting with its
+// last word, and indexed terms completing the last word, or close to it when none do.
+// Only the pages the viewer may see are suggested.
+func (s *SearchService) Suggest(text string, access *PageAccess) (*SearchSuggestions, error) {
+	suggestions := &SearchSuggestions{Pages: make([]*PageMeta, 0), Terms: make([]string, 0)}
+	text = strings.ToLower(strings.TrimLeft(text, " "))
+	words := strings.Fields(text)
+	if len([]rune(text)) < minSuggestionLength || len(words) == 0 {
+		return suggestions, nil
+	}
+	lastWord := words[len(words)-1]
+
+	allPages, err := s.PageRepository.GetAllPages(true)
+	if err != nil {
+		return nil, err
+	}
+	visiblePages := access.FilterPages(allPages)
+	hidesPages := len(visiblePages) < len(allPages)
+	titleMatches := make([]*PageMeta, 0)
+	wordMatches := make([]*PageMeta, 0)
+	for _, page := range visiblePages {
+		title := strings.ToLower(page.Title)
+		if strings.HasPrefix(title, strings.TrimSpace(text)) {
+			titleMatches = append(titleMatches, page)
+			continue
+		}
+		for _, word := range strings.Fields(title) {
+			if strings.HasPrefix(word, lastWord) {
+				wordMatches = append(wordMatches, page)
+				break
+			}
+		}
+	}
+	for _, matches := range [][]*PageMeta{titleMatches, wordMatches} {
+		sort.Slice(matches, func(i, j int) bool {
+			return matches[i].Title < matches[j].Title
+		})
+		for _, page := range matches {
+			if len(suggestions.Pages) < maxSuggestions {
+				suggestions.Pages = append(suggestions.Pages, page)
 			}
-			chineseText := runes[start:i]
+		}
+	}
 
-			// Generate 2-grams only if we have 2 or more Chinese characters
-			if len(chineseText) >= 2 {
-				for j := 0; j < len(chineseText)-1; j++ {
-					term := string(chineseText[j : j+2])
-					uniqueTerms[term] = true
-				}
+	if strings.HasSuffix(text, " ") {
+		return suggestions, nil
+	}
+	dictionary, err := s.getDictionary()
+	if err != nil {
+		return nil, err
+	}
+	// Terms found only in pages hidden from the viewer must not be suggested, so more
+	// matches are fetched and the hidden ones dropped.
+	limit := maxSuggestions
+	if hidesPages {
+		limit = maxTermExpansions
+	}
+	terms := dictionary.PrefixMatches(lastWord, limit)
+	if len(terms) == 0 {
+		terms = dictionary.FuzzyMatches(lastWord, limit)
+	}
+	if hidesPages {
+		visiblePageIds := make(map[int]bool, len(visiblePages))
+		for _, page := range visiblePages {
+			visiblePageIds[page.ID] = true
+		}
+		if terms, err = s.filterVisibleTerms(terms, visiblePageIds); err != nil {
+			return nil, err
+		}
+	}
+	if len(terms) > maxSuggestions {
+		terms = terms[:maxSuggestions]
+	}
+	suggestions.Terms = terms
+	return suggestions, nil
+}
+
+// filterVisibleTerms keeps the terms found in at least one of the visible pages.
+func (s *SearchService) filterVisibleTerms(terms []string, visiblePages map[int]bool) ([]string, error) {
+	result := make([]string, 0, len(terms))
+	for _, term := range terms {
+		searchTermList, err := s.index().terms.GetSearchTermList(term)
+		if err != nil {
+			return nil, err
+		}
+		if searchTermList == nil {
+			continue
+		}
+		for _, posting := range searchTermList.GetPostings() {
+			if visiblePages[posting.PageID] {
+				result = append(result, term)
+				break
 			}
-			i-- // Adjust for the outer loop increment
-		} else if unicode.Is(unicode.Latin, runes[i]) || unicode.IsDigit(runes[i]) {
-			// Start collecting Latin-based word
-			start := i
-			for i < len(runes) && (unicode.Is(unicode.Latin, runes[i]) || unicode.IsDigit(runes[i])) {
-				i++
+		}
+	}
+	return result, nil
+}
+
+// getCandidatePages returns the pages containing a term of the query, or all pages
+// when the query can match pages without any of its terms.
+func getCandidatePages(query *SearchQuery, postings termPostings, pageMap map[int]*PageMeta) []int {
+	if query.NeedsAllPages() {
+		ids := make([]int, 0, len(pageMap))
+		for id := range pageMap {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	seen := make(map[int]bool)
+	ids := make([]int, 0)
+	for _, group := range query.Groups {
+		for _, clause := range group {
+			if !clause.IsTextClause() || clause.Occur == QueryMustNot {
+				continue
 			}
-			word := strings.ToLower(string(runes[start:i]))
-			if len(word) > 2 && !stopwords[word] {
-				uniqueTerms[word] = true
+			for _, term := range clause.Terms {
+				for pageId := range postings[term] {
+					if !seen[pageId] {
+						seen[pageId] = true
+						ids = append(ids, pageId)
+					}
+				}
 			}
-			i-- // Adjust for the outer loop increment
 		}
-		// Skip other Unicode scripts (as requested)
 	}
+	return ids
+}
 
-	// Convert map to slice
-	result := make([]string, 0, len(uniqueTerms))
-	for term := range uniqueTerms {
-		result = append(result, term)
+// searchCandidate is a page matching the text of a query. The full page is only read
+// when a filter, the sort order or the results need it.
+type searchCandidate struct {
+	meta  *PageMeta
+	page  *Page
+	score float64
+}
+
+// pageFilter evaluates the tag:, author: and under: clauses of a query. Pages are
+// checked against their metadata first; the pages of under: filters are loaded once,
+// when first needed.
+type pageFilter struct {
+	service *SearchService
+	pageMap map[int]*PageMeta
+	roots   map[string]*Page
+}
+
+func (s *SearchService) newPageFilter() (*pageFilter, error) {
+	allPages, err := s.PageRepository.GetAllPages(true)
+	if err != nil {
+		return nil, err
+	}
+	filter := &pageFilter{service: s, pageMap: make(map[int]*PageMeta, len(allPages))}
+	for _, page := range allPages {
+		filter.pageMap[page.ID] = page
 	}
+	return filter, nil
+}
 
-	return result
+// getPage reads the full page of a candidate. It returns nil when the page was deleted
+// in the meantime.
+func (f *pageFilter) getPage(candidate *searchCandidate) (*Page, error) {
+	if candidate.page == nil {
+		page, err := f.service.PageRepository.GetPageByID(candidate.meta.ID)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil || page.IsDeleted() {
+			return nil, nil
+		}
+		candidate.page = page
+	}
+	return candidate.page, nil
 }
 
-func (s *SearchService) RebuildSearchIndex() error {
-	// Get all pages from the repository
-	pages, err := s.PageRepository.GetAllPages(false)
+// matchesPage checks a page against the filters of the query and the search options.
+func (f *pageFilter) matchesPage(query *SearchQuery, options *SearchOptions, candidate *searchCandidate, postings termPostings) (bool, error) {
+	meta := candidate.meta
+	if meta == nil || meta.DeletedAt != nil {
+		return false, nil
+	}
+	if meta.IsProtected && !options.IncludeProtected {
+		return false, nil
+	}
+	if options.Access != nil && !options.Access.CanView(meta.ID, meta.ParentID, meta.IsProtected) {
+		return false, nil
+	}
+	if !options.ModifiedSince.IsZero() || !options.ModifiedUntil.IsZero() {
+		page, err := f.getPage(candidate)
+		if err != nil || page == nil {
+			return false, err
+		}
+		if !options.ModifiedSince.IsZero() && page.LastModifiedAt.Before(options.ModifiedSince) {
+			return false, nil
+		}
+		if !options.ModifiedUntil.IsZero() && !page.LastModifiedAt.Before(options.ModifiedUntil) {
+			return false, nil
+		}
+	}
+	return f.matches(query, candidate, postings)
+}
+
+// matches evaluates the groups holding a filter. Text clauses joined to a filter
+// by OR are checked against the index postings.
+func (f *pageFilter) matches(query *SearchQuery, candidate *searchCandidate, postings termPostings) (bool, error) {
+	var err error
+	matchesClause := func(clause *QueryClause) bool {
+		if clause.IsTextClause() {
+			return clause.matchesText(candidate.meta.ID, postings)
+		}
+		matched, e := f.matchesClause(clause, candidate)
+		if e != nil {
+			err = e
+		}
+		return matched
+	}
+	for _, group := range query.Groups {
+		if isTextGroup(group) {
+			continue
+		}
+		if !isGroupSatisfied(group, matchesClause) || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (f *pageFilter) matchesClause(clause *QueryClause, candidate *searchCandidate) (bool, error) {
+	switch clause.Field {
+	case QueryFieldTag:
+		page, err := f.getPage(candidate)
+		if err != nil || page == nil {
+			return false, err
+		}
+		for _, tag := range page.Tags {
+			if strings.EqualFold(tag, clause.Value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case QueryFieldAuthor:
+		page, err := f.getPage(candidate)
+		if err != nil || page == nil {
+			return false, err
+		}
+		return strings.EqualFold(page.CreatedBy, clause.Value) || strings.EqualFold(page.LastModifiedBy, clause.Value), nil
+	case QueryFieldUnder:
+		root, err := f.getRoot(clause.Value)
+		if err != nil || root == nil {
+			return false, err
+		}
+		return isDescendantOrSelf(candidate.meta.ID, candidate.meta.ParentID, root.ID, f.pageMap), nil
+	case queryFieldParentID:
+		rootID, err := strconv.Atoi(clause.Value)
+		if err != nil {
+			return false, nil
+		}
+		return isDescendantOrSelf(candidate.meta.ID, candidate.meta.ParentID, rootID, f.pageMap), nil
+	}
+	return false, nil
+}
+
+func (f *pageFilter) getRoot(url string) (*Page, error) {
+	if len(url) > 1 {
+		url = strings.TrimSuffix(url, "/")
+	}
+	if f.roots == nil {
+		f.roots = make(map[string]*Page)
+	}
+	if root, ok := f.roots[url]; ok {
+		return root, nil
+	}
+	root, err := f.service.PageRepository.GetPageByUrl(url)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	if root != nil && root.IsDeleted() {
+		root = nil
+	}
+	f.roots[url] = root
+	return root, nil
+}
 
-	// Clear existing search terms
-	if err := s.SearchTermListRepository.DeleteAll(); err != nil {
-		return err
+func isDescendantOrSelf(pageId int, parentID *int, rootID int, pageMap map[int]*PageMeta) bool {
+	if pageId == rootID {
+		return true
 	}
+	visited := map[int]bool{pageId: true}
+	for parentID != nil && !visited[*parentID] {
+		if *parentID == rootID {
+			return true
+		}
+		visited[*parentID] = true
+		parent, ok := pageMap[*parentID]
+		if !ok {
+			return false
+		}
+		parentID = parent.ParentID
+	}
+	return false
+}
+
+func bm25Idf(docCount int, docFreq int) float64 {
+	return math.Log(1 + (float64(docCount-docFreq)+0.5)/(float64(docFreq)+0.5))
+}
+
+// bm25TermScore combines the field frequencies of a term into one weighted,
+// length-normalized frequency and saturates it with k1.
+func bm25TermScore(posting *TermPosting, stats *searchIndexStats) float64 {
+	tf := 0.0
+	for field, freq := range posting.Frequencies {
+		tf += searchFieldWeights[field] * float64(freq) / stats.lengthNorm(posting.PageID, field)
+	}
+	return tf / (bm25K1 + tf)
+}
+
+// buildSearchIndexEntry counts the terms of each searchable field of a page. Attachments
+// map the media URL of each attached file to its text.
+func buildSearchIndexEntry(page *Page, attachments map[string]string, tokenizer Tokenizer) (map[string]*TermPosting, *SearchDocument) {
+	fields := map[SearchField]string{
+		SearchFieldTitle:       page.Title,
+		SearchFieldTags:        strings.Join(page.Tags, " "),
+		SearchFieldDescription: page.ShortDesc,
+		SearchFieldBody:        page.Content,
+	}
+	postings := make(map[string]*TermPosting)
+	doc := &SearchDocument{
+		PageID:       page.ID,
+		FieldLengths: make(map[SearchField]int, len(fields)+1),
+		Version:      searchIndexVersion,
+		Tokenizer:    tokenizer.Name(),
+	}
+	if len(attachments) > 0 {
+		for url := range attachments {
+			doc.Attachments = append(doc.Attachments, url)
+		}
+		sort.Strings(doc.Attachments)
+		texts := make([]string, len(doc.Attachments))
+		for i, url := range doc.Attachments {
+			texts[i] = attachments[url]
+		}
+		fields[SearchFieldAttachment] = strings.Join(texts, "\n")
+	}
+	for field, text := range fields {
+		tokens := tokenizeAll(tokenizer, text)
+		doc.FieldLengths[field] = len(tokens)
+		for position, token := range tokens {
+			posting, ok := postings[token]
+			if !ok {
+				posting = &TermPosting{
+					PageID:      page.ID,
+					Frequencies: make(map[SearchField]int),
+					Positions:   make(map[SearchField][]int),
+				}
+				postings[token] = posting
+			}
+			posting.Frequencies[field]++
+			posting.Positions[field] = append(posting.Positions[field], position)
+		}
+	}
+	doc.Terms = make([]string, 0, len(postings))
+	for term := range postings {
+		doc.Terms = append(doc.Terms, term)
+	}
+	sort.Strings(doc.Terms)
+	return postings, doc
+}
+
+func (s *SearchService) AddPageSearchTerms(page *Page) error {
+	if page == nil {
+		return nil // No valid page to add
+	}
+	return s.indexPage(page, nil)
+}
+
+func (s *SearchService) UpdatePageSearchTerms(page *Page, oldPage *Page) error {
+	if page == nil || page.ID <= 0 {
+		return nil // No valid page to update
+	}
+	return s.indexPage(page, oldPage)
+}
 
-	// Rebuild search terms for each page
-	for _, page := range pages {
-		pageWithContent, err := s.PageRepository.GetPageByID(page.ID)
+func (s *SearchService) DeletePageSearchTerms(page *Page) error {
+	defer s.invalidateDictionary()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.markDirty(page.ID)
+	return s.index().delete(page.ID, page, s.getTokenizer())
+}
+
+func (s *SearchService) indexPage(page *Page, oldPage *Page) error {
+	defer s.invalidateDictionary()
+	attachments := s.readAttachments(page)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.markDirty(page.ID)
+	return s.index().write(page, attachments, s.getTokenizer(), oldPage)
+}
+
+// SetIndexAttachments turns indexing of the text of PDF, TXT and Markdown files linked
+// from pages under /uploads on or off. The index has to be rebuilt afterwards.
+func (s *SearchService) SetIndexAttachments(enabled bool) {
+	s.indexAttachments.Store(enabled)
+}
+
+// readAttachments returns the text of the uploaded files a page links to, keyed by their
+// media URL. Files that cannot be read are skipped so the page itself is still indexed.
+func (s *SearchService) readAttachments(page *Page) map[string]string {
+	if !s.indexAttachments.Load() || s.FileManager == nil {
+		return nil
+	}
+	attachments := make(map[string]string)
+	for _, ref := range extractMediaRefs(page.Content) {
+		if !strings.HasPrefix(ref, "/uploads/") || !IsIndexableAttachment(ref) {
+			continue
+		}
+		dir, file := path.Split(ref)
+		data, err := s.FileManager.ReadFile(file, dir)
 		if err != nil {
-			return err
+			log.Printf("failed to read attachment %s of page %d: %v\n", ref, page.ID, err)
+			continue
 		}
-		if err := s.AddPageSearchTerms(pageWithContent); err != nil {
-			return err
+		if text, ok := ExtractAttachmentText(file, data); ok {
+			attachments["/media"+ref] = text
 		}
 	}
-	return nil
+	return attachments
+}
+
+// Tokenize returns the distinct search terms of a text using the default tokenizer.
+func Tokenize(text string) []string {
+	return tokenizeUnique(DefaultTokenizer{}, text)
+}
+
+// TokenizeAll returns the search terms of a text in order, including repeated terms,
+// using the default tokenizer.
+func TokenizeAll(text string) []string {
+	return tokenizeAll(DefaultTokenizer{}, text)
 }
 
-func isChinese(r rune) bool {
-	return unicode.Is(unicode.Han, r)
+// RebuildSearchIndexIfOutdated starts a rebuild of the search index when pages exist but
+// were indexed by an older version of the index format or another tokenizer, or not at
+// all. An unfinished rebuild is resumed.
+func (s *SearchService) RebuildSearchIndexIfOutdated() error {
+	if s.IndexStore == nil {
+		return ErrSearchIndexStoreMissing
+	}
+	state, err := s.IndexStore.GetState()
+	if err != nil {
+		return err
+	}
+	if state.Building != "" {
+		_, err := s.StartRebuildSearchIndex()
+		return err
+	}
+	docs, err := s.index().docs.ListSearchDocuments()
+	if err != nil {
+		return err
+	}
+	tokenizer := s.getTokenizer().Name()
+	outdated := len(docs) == 0
+	for _, doc := range docs {
+		docTokenizer := doc.Tokenizer
+		if docTokenizer == "" {
+			docTokenizer = DefaultTokenizer{}.Name()
+		}
+		if doc.Version < searchIndexVersion || docTokenizer != tokenizer {
+			outdated = true
+			break
+		}
+	}
+	if !outdated {
+		return nil
+	}
+	pages, err := s.PageRepository.GetAllPages(true)
+	if err != nil || len(pages) == 0 {
+		return err
+	}
+	_, err = s.StartRebuildSearchIndex()
+	return err
 }
@@ -1,6 +1,8 @@
 package pages
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -116,6 +118,80 @@ func TestTokenizeEdgeCases(t *testing.T) {
 	}
 }
 
+func TestTokenizeAllKeepsRepeatedTerms(t *testing.T) {
+	result := TokenizeAll("<p>Deploy the app, then deploy again</p>")
+	expected := []string{"deploy", "app", "then", "deploy", "again"}
+	if len(result) != len(expected) {
+		t.Fatalf("TokenizeAll() = %v, expected %v", result, expected)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("TokenizeAll()[%d] = %q, expected %q", i, result[i], expected[i])
+		}
+	}
+}
+
+func TestBM25RanksTitleMatchesHigher(t *testing.T) {
+	titlePostings, titleDoc := buildSearchIndexEntry(&Page{ID: 1, Title: "Deployment", Content: "<p>Steps for the release process</p>"}, nil, DefaultTokenizer{})
+	bodyPostings, bodyDoc := buildSearchIndexEntry(&Page{ID: 2, Title: "Release notes", Content: "<p>Notes about deployment</p>"}, nil, DefaultTokenizer{})
+	longPostings, longDoc := buildSearchIndexEntry(&Page{ID: 3, Title: "Handbook", Content: "<p>Deployment " + strings.Repeat("filler words here ", 50) + "</p>"}, nil, DefaultTokenizer{})
+
+	stats := newSearchIndexStats([]*SearchDocument{titleDoc, bodyDoc, longDoc})
+
+	titleScore := bm25TermScore(titlePostings["deployment"], stats)
+	bodyScore := bm25TermScore(bodyPostings["deployment"], stats)
+	longScore := bm25TermScore(longPostings["deployment"], stats)
+	if titleScore <= bodyScore {
+		t.Errorf("title match score %f should be higher than body match score %f", titleScore, bodyScore)
+	}
+	if bodyScore <= longScore {
+		t.Errorf("short body match score %f should be higher than long body match score %f", bodyScore, longScore)
+	}
+}
+
+func TestSearchIndexStatsFollowWrites(t *testing.T) {
+	docs := []*SearchDocument{
+		{PageID: 1, FieldLengths: map[SearchField]int{SearchFieldTitle: 2, SearchFieldBody: 10}},
+		{PageID: 2, FieldLengths: map[SearchField]int{SearchFieldTitle: 4, SearchFieldBody: 30}, Attachments: []string{"/media/uploads/a.pdf"}},
+	}
+	stats := newSearchIndexStats(docs)
+	stats.add(&SearchDocument{PageID: 1, FieldLengths: map[SearchField]int{SearchFieldTitle: 6, SearchFieldBody: 20}})
+	stats.remove(2)
+	stats.add(&SearchDocument{PageID: 3, FieldLengths: map[SearchField]int{SearchFieldTitle: 2, SearchFieldBody: 40}})
+
+	expected := newSearchIndexStats([]*SearchDocument{
+		{PageID: 1, FieldLengths: map[SearchField]int{SearchFieldTitle: 6, SearchFieldBody: 20}},
+		{PageID: 3, FieldLengths: map[SearchField]int{SearchFieldTitle: 2, SearchFieldBody: 40}},
+	})
+	if stats.docCount() != 2 {
+		t.Errorf("docCount() = %d, expected 2", stats.docCount())
+	}
+	if !reflect.DeepEqual(stats.lengthSums, expected.lengthSums) {
+		t.Errorf("lengthSums = %v, expected %v", stats.lengthSums, expected.lengthSums)
+	}
+	if got := stats.lengthNorm(1, SearchFieldBody); got != expected.lengthNorm(1, SearchFieldBody) {
+		t.Errorf("lengthNorm() = %f, expected %f", got, expected.lengthNorm(1, SearchFieldBody))
+	}
+	if stats.getAttachments(2) != nil {
+		t.Error("getAttachments() returned the attachments of a removed page")
+	}
+}
+
+func TestPageFilterHidesProtectedPages(t *testing.T) {
+	query := ParseSearchQuery("secret", DefaultTokenizer{})
+	candidate := &searchCandidate{meta: &PageMeta{ID: 1, Title: "Secret", IsProtected: true}}
+	filter := &pageFilter{}
+	for _, includeProtected := range []bool{false, true} {
+		matched, err := filter.matchesPage(query, &SearchOptions{IncludeProtected: includeProtected}, candidate, termPostings{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != includeProtected {
+			t.Errorf("matchesPage with IncludeProtected=%v = %v", includeProtected, matched)
+		}
+	}
+}
+
 func BenchmarkTokenize(b *testing.B) {
 	testCases := []struct {
 		name  string
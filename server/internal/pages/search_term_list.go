@@ -1,14 +1,81 @@
 package pages
 
 import (
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 )
 
+type SearchField string
+
+const (
+	SearchFieldTitle       SearchField = "title"
+	SearchFieldTags        SearchField = "tags"
+	SearchFieldDescription SearchField = "description"
+	SearchFieldBody        SearchField = "body"
+	// SearchFieldAttachment holds the text of the files a page links to under /uploads
+	SearchFieldAttachment SearchField = "attachment"
+)
+
+// TermPosting records how often and at which token positions a term occurs
+// in each field of a page.
+type TermPosting struct {
+	PageID      int                   `json:"page_id"`
+	Frequencies map[SearchField]int   `json:"frequencies"`
+	Positions   map[SearchField][]int `json:"positions,omitempty"`
+}
+
 type SearchTermList struct {
-	ID      int    `json:"id"`
-	Term    string `json:"term" validate:"required"`
-	PageIds []int  `json:"page_ids" validate:"required"`
+	ID       int            `json:"id"`
+	Term     string         `json:"term" validate:"required"`
+	PageIds  []int          `json:"page_ids" validate:"required"`
+	Postings []*TermPosting `json:"postings,omitempty"`
+}
+
+// GetPostings returns the postings of the term. Lists indexed before term frequencies
+// were recorded count as a single body occurrence per page.
+func (s *SearchTermList) GetPostings() []*TermPosting {
+	if len(s.Postings) > 0 || len(s.PageIds) == 0 {
+		return s.Postings
+	}
+	postings := make([]*TermPosting, len(s.PageIds))
+	for i, pageId := range s.PageIds {
+		postings[i] = &TermPosting{PageID: pageId, Frequencies: map[SearchField]int{SearchFieldBody: 1}}
+	}
+	return postings
+}
+
+// SetPosting adds or replaces the posting of a page and reports whether the list changed.
+// Positions are compared too, as reordering the words of a page keeps the frequencies.
+func (s *SearchTermList) SetPosting(posting *TermPosting) bool {
+	// Lists without stored postings are written even when the synthesized posting matches
+	stored := len(s.Postings) > 0
+	postings := s.GetPostings()
+	for i, existing := range postings {
+		if existing.PageID == posting.PageID {
+			if stored && existing.equal(posting) {
+				return false
+			}
+			postings[i] = posting
+			s.Postings = postings
+			return true
+		}
+	}
+	s.Postings = append(postings, posting)
+	s.PageIds = append(s.PageIds, posting.PageID)
+	return true
+}
+
+// RemovePosting removes the page from the list.
+func (s *SearchTermList) RemovePosting(pageId int) {
+	s.PageIds = slices.DeleteFunc(s.PageIds, func(id int) bool { return id == pageId })
+	s.Postings = slices.DeleteFunc(s.GetPostings(), func(p *TermPosting) bool { return p.PageID == pageId })
+}
+
+func (p *TermPosting) equal(other *TermPosting) bool {
+	return maps.Equal(p.Frequencies, other.Frequencies) &&
+		maps.EqualFunc(p.Positions, other.Positions, func(a, b []int) bool { return slices.Equal(a, b) })
 }
 
 func (s *SearchTermList) GetID() int {
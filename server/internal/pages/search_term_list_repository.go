@@ -3,6 +3,7 @@ package pages
 type SearchTermListRepository interface {
 	Init() error
 	GetSearchTermList(term string) (*SearchTermList, error)
-	UpdateSearchTermLists(terms, oldTerms []string, pageId int) error
+	ListTerms() ([]string, error)
+	UpdateSearchTermLists(postings map[string]*TermPosting, oldTerms []string, pageId int) error
 	DeleteAll() error
 }
@@ -0,0 +1,216 @@
+package pages
+
+import "strings"
+
+// StemEnglish reduces an English word to its stem with the Porter stemming algorithm,
+// so "deploying", "deployed" and "deploys" all match "deploy". Words with letters
+// outside a-z are returned unchanged.
+func StemEnglish(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+	s := &porterStemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+type porterStemmer struct {
+	b []byte
+}
+
+func (s *porterStemmer) isConsonant(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.isConsonant(i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in b[:end].
+func (s *porterStemmer) measure(end int) int {
+	n, i := 0, 0
+	for i < end && s.isConsonant(i) {
+		i++
+	}
+	for i < end {
+		for i < end && !s.isConsonant(i) {
+			i++
+		}
+		if i >= end {
+			break
+		}
+		n++
+		for i < end && s.isConsonant(i) {
+			i++
+		}
+	}
+	return n
+}
+
+func (s *porterStemmer) hasVowel(end int) bool {
+	for i := 0; i < end; i++ {
+		if !s.isConsonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *porterStemmer) endsWithDoubleConsonant(end int) bool {
+	return end >= 2 && s.b[end-1] == s.b[end-2] && s.isConsonant(end-1)
+}
+
+// endsWithCVC checks for consonant-vowel-consonant at the end of b[:end],
+// where the last consonant is not w, x or y, e.g. "hop" in "hoping".
+func (s *porterStemmer) endsWithCVC(end int) bool {
+	if end < 3 || !s.isConsonant(end-1) || s.isConsonant(end-2) || !s.isConsonant(end-3) {
+		return false
+	}
+	c := s.b[end-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+func (s *porterStemmer) hasSuffix(suffix string) bool {
+	return strings.HasSuffix(string(s.b), suffix)
+}
+
+func (s *porterStemmer) stemLength(suffix string) int {
+	return len(s.b) - len(suffix)
+}
+
+func (s *porterStemmer) replace(suffix, replacement string) {
+	s.b = append(s.b[:s.stemLength(suffix)], replacement...)
+}
+
+// replaceIfMeasure replaces the first matching suffix when the remaining stem has a
+// measure greater than min. It reports whether a suffix matched.
+func (s *porterStemmer) replaceIfMeasure(rules [][2]string, min int) bool {
+	for _, rule := range rules {
+		if s.hasSuffix(rule[0]) {
+			if s.measure(s.stemLength(rule[0])) > min {
+				s.replace(rule[0], rule[1])
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (s *porterStemmer) step1a() {
+	switch {
+	case s.hasSuffix("sses"):
+		s.replace("sses", "ss")
+	case s.hasSuffix("ies"):
+		s.replace("ies", "i")
+	case s.hasSuffix("ss"):
+	case s.hasSuffix("s"):
+		s.replace("s", "")
+	}
+}
+
+func (s *porterStemmer) step1b() {
+	if s.hasSuffix("eed") {
+		if s.measure(s.stemLength("eed")) > 0 {
+			s.replace("eed", "ee")
+		}
+		return
+	}
+	removed := false
+	for _, suffix := range []string{"ed", "ing"} {
+		if s.hasSuffix(suffix) && s.hasVowel(s.stemLength(suffix)) {
+			s.replace(suffix, "")
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return
+	}
+	switch {
+	case s.hasSuffix("at"), s.hasSuffix("bl"), s.hasSuffix("iz"):
+		s.b = append(s.b, 'e')
+	case s.endsWithDoubleConsonant(len(s.b)):
+		if c := s.b[len(s.b)-1]; c != 'l' && c != 's' && c != 'z' {
+			s.b = s.b[:len(s.b)-1]
+		}
+	case s.measure(len(s.b)) == 1 && s.endsWithCVC(len(s.b)):
+		s.b = append(s.b, 'e')
+	}
+}
+
+func (s *porterStemmer) step1c() {
+	if s.hasSuffix("y") && s.hasVowel(s.stemLength("y")) {
+		s.replace("y", "i")
+	}
+}
+
+var porterStep2Rules = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+var porterStep3Rules = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var porterStep4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func (s *porterStemmer) step2() {
+	s.replaceIfMeasure(porterStep2Rules, 0)
+}
+
+func (s *porterStemmer) step3() {
+	s.replaceIfMeasure(porterStep3Rules, 0)
+}
+
+func (s *porterStemmer) step4() {
+	// The longest matching suffix is removed, so "ement" is tried before "ment" and "ent".
+	longest := ""
+	for _, suffix := range porterStep4Suffixes {
+		if s.hasSuffix(suffix) && len(suffix) > len(longest) {
+			longest = suffix
+		}
+	}
+	if longest == "" {
+		return
+	}
+	end := s.stemLength(longest)
+	if longest == "ion" && (end == 0 || (s.b[end-1] != 's' && s.b[end-1] != 't')) {
+		return
+	}
+	if s.measure(end) > 1 {
+		s.b = s.b[:end]
+	}
+}
+
+func (s *porterStemmer) step5() {
+	if s.hasSuffix("e") {
+		end := s.stemLength("e")
+		if m := s.measure(end); m > 1 || (m == 1 && !s.endsWithCVC(end)) {
+			s.b = s.b[:end]
+		}
+	}
+	if s.hasSuffix("ll") && s.measure(len(s.b)) > 1 {
+		s.b = s.b[:len(s.b)-1]
+	}
+}
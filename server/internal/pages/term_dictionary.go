@@ -0,0 +1,115 @@
+package pages
+
+import (
+	"sort"
+	"strings"
+)
+
+// TermDictionary is the sorted list of indexed terms, used to expand query terms
+// by prefix and to find terms within a small edit distance of a misspelled term.
+type TermDictionary struct {
+	terms []string
+}
+
+func NewTermDictionary(terms []string) *TermDictionary {
+	sorted := make([]string, len(terms))
+	copy(sorted, terms)
+	sort.Strings(sorted)
+	return &TermDictionary{terms: sorted}
+}
+
+// PrefixMatches returns up to limit terms starting with prefix, in sorted order.
+func (d *TermDictionary) PrefixMatches(prefix string, limit int) []string {
+	result := make([]string, 0)
+	for i := sort.SearchStrings(d.terms, prefix); i < len(d.terms); i++ {
+		if !strings.HasPrefix(d.terms[i], prefix) || (limit > 0 && len(result) >= limit) {
+			break
+		}
+		result = append(result, d.terms[i])
+	}
+	return result
+}
+
+// FuzzyMatches returns up to limit terms within the edit distance allowed for the term,
+// closest first. Short terms are not matched fuzzily.
+func (d *TermDictionary) FuzzyMatches(term string, limit int) []string {
+	target := []rune(term)
+	maxDistance := maxEditDistance(len(target))
+	if maxDistance == 0 {
+		return []string{}
+	}
+	type match struct {
+		term     string
+		distance int
+	}
+	matches := make([]match, 0)
+	for _, candidate := range d.terms {
+		runes := []rune(candidate)
+		if abs(len(runes)-len(target)) > maxDistance || candidate == term {
+			continue
+		}
+		if distance := boundedEditDistance(target, runes, maxDistance); distance <= maxDistance {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+	result := make([]string, 0, len(matches))
+	for _, m := range matches {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, m.term)
+	}
+	return result
+}
+
+func maxEditDistance(length int) int {
+	switch {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// boundedEditDistance computes the edit distance of a and b, counting a swap of two
+// adjacent letters as one edit. It gives up with limit+1 as soon as every alignment exceeds limit.
+func boundedEditDistance(a, b []rune, limit int) int {
+	beforePrevious := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = min(current[j], beforePrevious[j-2]+1)
+			}
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		beforePrevious, previous, current = previous, current, beforePrevious
+	}
+	return previous[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
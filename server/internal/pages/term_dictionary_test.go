@@ -0,0 +1,38 @@
+package pages
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTermDictionaryPrefixMatches(t *testing.T) {
+	dictionary := NewTermDictionary([]string{"kubernetes", "kubectl", "docker", "kube", "kafka"})
+	if got := dictionary.PrefixMatches("kube", 0); !reflect.DeepEqual(got, []string{"kube", "kubectl", "kubernetes"}) {
+		t.Errorf("PrefixMatches(kube) = %v", got)
+	}
+	if got := dictionary.PrefixMatches("kube", 2); len(got) != 2 {
+		t.Errorf("PrefixMatches(kube, 2) = %v, expected 2 terms", got)
+	}
+	if got := dictionary.PrefixMatches("zzz", 0); len(got) != 0 {
+		t.Errorf("PrefixMatches(zzz) = %v, expected none", got)
+	}
+}
+
+func TestTermDictionaryFuzzyMatches(t *testing.T) {
+	dictionary := NewTermDictionary([]string{"kubernetes", "deployment", "deploy", "docker", "dockerfile"})
+	tests := []struct {
+		term     string
+		expected []string
+	}{
+		{"kubernets", []string{"kubernetes"}},
+		{"deploymnet", []string{"deployment"}},
+		{"dokcer", []string{"docker"}},
+		{"doc", []string{}},
+		{"python", []string{}},
+	}
+	for _, tt := range tests {
+		if got := dictionary.FuzzyMatches(tt.term, 0); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("FuzzyMatches(%q) = %v, expected %v", tt.term, got, tt.expected)
+		}
+	}
+}
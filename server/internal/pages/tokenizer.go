@@ -0,0 +1,179 @@
+package pages
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`) // matches anything between < and >
+
+// Common English stopwords
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "he": true, "in": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "that": true, "the": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// Token is a search term with its rune offsets in the tokenized text.
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenizer splits plain text into search terms. The same tokenizer has to be used
+// for indexing and for queries, so the index is rebuilt when it changes.
+type Tokenizer interface {
+	Name() string
+	Tokenize(text []rune) []Token
+}
+
+// languageStopwords holds the stopwords of each language that has a list. Words of a
+// language without a list are all kept.
+var languageStopwords = map[string]map[string]bool{
+	"en": stopwords,
+}
+
+// NewTokenizer returns the tokenizer for the site language. English text is stemmed,
+// other languages are segmented without stemming. Every tokenizer except the default
+// one splits Chinese, Japanese and Korean into bigrams and keeps words of other scripts.
+func NewTokenizer(language string) Tokenizer {
+	language = strings.ToLower(language)
+	switch language {
+	case "":
+		return DefaultTokenizer{}
+	case "en":
+		return &UnicodeTokenizer{Language: "en", Stopwords: languageStopwords["en"], Stem: StemEnglish}
+	default:
+		return &UnicodeTokenizer{Language: language, Stopwords: languageStopwords[language]}
+	}
+}
+
+func tokenizeAll(tokenizer Tokenizer, text string) []string {
+	plain := htmlTagRegexp.ReplaceAllString(text, " ")
+	tokens := tokenizer.Tokenize([]rune(plain))
+	result := make([]string, len(tokens))
+	for i, token := range tokens {
+		result[i] = token.Term
+	}
+	return result
+}
+
+func tokenizeUnique(tokenizer Tokenizer, text string) []string {
+	uniqueTerms := make(map[string]bool)
+	result := make([]string, 0)
+	for _, term := range tokenizeAll(tokenizer, text) {
+		if !uniqueTerms[term] {
+			uniqueTerms[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// DefaultTokenizer keeps Latin words of three letters or more without English stopwords,
+// splits Chinese into bigrams and ignores other scripts. It is used when no language is set.
+type DefaultTokenizer struct{}
+
+func (DefaultTokenizer) Name() string {
+	return "default"
+}
+
+func (DefaultTokenizer) Tokenize(runes []rune) []Token {
+	result := make([]Token, 0)
+
+	// Process text character by character, handling different scripts separately
+	for i := 0; i < len(runes); i++ {
+		if isChinese(runes[i]) {
+			// Find consecutive Chinese characters
+			start := i
+			for i < len(runes) && isChinese(runes[i]) {
+				i++
+			}
+
+			// Generate 2-grams only if we have 2 or more Chinese characters
+			if i-start >= 2 {
+				for j := start; j < i-1; j++ {
+					result = append(result, Token{string(runes[j : j+2]), j, j + 2})
+				}
+			}
+			i-- // Adjust for the outer loop increment
+		} else if unicode.Is(unicode.Latin, runes[i]) || unicode.IsDigit(runes[i]) {
+			// Start collecting Latin-based word
+			start := i
+			for i < len(runes) && (unicode.Is(unicode.Latin, runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			word := strings.ToLower(string(runes[start:i]))
+			if len(word) > 2 && !stopwords[word] {
+				result = append(result, Token{word, start, i})
+			}
+			i-- // Adjust for the outer loop increment
+		}
+		// Skip other Unicode scripts (as requested)
+	}
+
+	return result
+}
+
+// UnicodeTokenizer splits text at Unicode word boundaries: runs of letters, digits and
+// combining marks of any script form a word. Han, kana and Hangul are written without
+// spaces between words, so runs of them are split into overlapping bigrams instead. A
+// single character of these scripts is kept as it is, so it can be searched for.
+type UnicodeTokenizer struct {
+	Language  string
+	Stopwords map[string]bool
+	Stem      func(word string) string
+}
+
+func (t *UnicodeTokenizer) Name() string {
+	return "unicode-" + t.Language
+}
+
+func (t *UnicodeTokenizer) Tokenize(runes []rune) []Token {
+	result := make([]Token, 0)
+	for i := 0; i < len(runes); {
+		switch {
+		case isBigramScript(runes[i]):
+			start := i
+			for i < len(runes) && isBigramScript(runes[i]) {
+				i++
+			}
+			if i-start == 1 {
+				result = append(result, Token{string(runes[start]), start, i})
+			}
+			for j := start; j < i-1; j++ {
+				result = append(result, Token{string(runes[j : j+2]), j, j + 2})
+			}
+		case isWordRune(runes[i]) && !unicode.IsMark(runes[i]):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) && !isBigramScript(runes[i]) {
+				i++
+			}
+			word := strings.ToLower(string(runes[start:i]))
+			if len([]rune(word)) < 2 || t.Stopwords[word] {
+				continue
+			}
+			if t.Stem != nil {
+				word = t.Stem(word)
+			}
+			result = append(result, Token{word, start, i})
+		default:
+			i++
+		}
+	}
+	return result
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isBigramScript(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || r == 'ー'
+}
+
+func isChinese(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
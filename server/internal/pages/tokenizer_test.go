@@ -0,0 +1,90 @@
+package pages
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnicodeTokenizer(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "English words are stemmed",
+			language: "en",
+			input:    "<p>Deploying the services, deployed by the deployer</p>",
+			expected: []string{"deploi", "servic", "deploi", "deploy"},
+		},
+		{
+			name:     "Russian words are kept",
+			language: "en",
+			input:    "Настройка сервера",
+			expected: []string{"настройка", "сервера"},
+		},
+		{
+			name:     "Japanese kana and kanji become bigrams",
+			language: "jp",
+			input:    "サーバー設定",
+			expected: []string{"サー", "ーバ", "バー", "ー設", "設定"},
+		},
+		{
+			name:     "Korean Hangul becomes bigrams",
+			language: "en",
+			input:    "서버 설정하기",
+			expected: []string{"서버", "설정", "정하", "하기"},
+		},
+		{
+			name:     "A single Han character is kept",
+			language: "cn",
+			input:    "云 服务器",
+			expected: []string{"云", "服务", "务器"},
+		},
+		{
+			name:     "English stopwords are kept in other languages",
+			language: "de",
+			input:    "Die Will des Volkes",
+			expected: []string{"die", "will", "des", "volkes"},
+		},
+		{
+			name:     "Other languages are not stemmed",
+			language: "fr",
+			input:    "Les serveurs déployés",
+			expected: []string{"les", "serveurs", "déployés"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenizeAll(NewTokenizer(tt.language), tt.input); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("tokenizeAll() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStemEnglish(t *testing.T) {
+	tests := map[string]string{
+		"caresses":    "caress",
+		"ponies":      "poni",
+		"cats":        "cat",
+		"agreed":      "agre",
+		"hopping":     "hop",
+		"filing":      "file",
+		"happy":       "happi",
+		"relational":  "relat",
+		"generalize":  "gener",
+		"adjustment":  "adjust",
+		"adoption":    "adopt",
+		"controlling": "control",
+		"running":     "run",
+		"go":          "go",
+		"café":        "café",
+	}
+	for word, expected := range tests {
+		if got := StemEnglish(word); got != expected {
+			t.Errorf("StemEnglish(%q) = %q, expected %q", word, got, expected)
+		}
+	}
+}
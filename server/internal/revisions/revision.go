@@ -5,10 +5,15 @@ import (
 	"time"
 )
 
+// Revision keeps the previous state of a record. Author, Comment and ChangeSize
+// describe the edit that replaced Record.
 type Revision[T interface{}] struct {
 	ID         int       `json:"id"`
 	RecordID   int       `json:"recordId"`
 	InsertDate time.Time `json:"insertDate"`
+	Author     string    `json:"author"`
+	Comment    string    `json:"comment"`
+	ChangeSize int       `json:"changeSize"`
 	Record     T         `json:"record"`
 }
 
@@ -26,6 +31,10 @@ func (r *Revision[T]) GetValue(field string) string {
 		return strconv.Itoa(r.RecordID)
 	case "InsertDate":
 		return r.InsertDate.String()
+	case "Author":
+		return r.Author
+	case "ChangeSize":
+		return strconv.Itoa(r.ChangeSize)
 	}
 	return ""
 }
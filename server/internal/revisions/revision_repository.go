@@ -2,6 +2,10 @@ package revisions
 
 type RevisionRepository[T interface{}] interface {
 	Init() error
+	GetRevision(id int) (*Revision[T], error)
 	GetLatestRevision(recordID int) (*Revision[T], error)
+	ListRevisions(recordID int, offset, limit int) ([]*Revision[T], int, error)
+	ListRevisionsByAuthor(author string, offset, limit int, visible func(*Revision[T]) bool) ([]*Revision[T], int, error)
 	AddRevision(e *Revision[T]) error
+	DeleteRevisions(recordID int) error
 }
@@ -10,12 +10,43 @@ func (s *RevisionService[T]) Init() error {
 	return s.Repository.Init()
 }
 
+func (s *RevisionService[T]) GetRevision(id int) (*Revision[T], error) {
+	return s.Repository.GetRevision(id)
+}
+
 func (s *RevisionService[T]) GetLatestRevision(recordID int) (*Revision[T], error) {
 	return s.Repository.GetLatestRevision(recordID)
 }
 
-func (s *RevisionService[T]) AddRevision(recordID int, record T) error {
-	e := &Revision[T]{RecordID: recordID, Record: record}
+// ListRevisions returns the revisions of a record, newest first, together with the total count.
+func (s *RevisionService[T]) ListRevisions(recordID int, offset, limit int) ([]*Revision[T], int, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	return s.Repository.ListRevisions(recordID, offset, limit)
+}
+
+// ListRevisionsByAuthor returns the revisions created by a user, newest first, together with the total count.
+// Revisions for which visible returns false are skipped before paging and not counted.
+func (s *RevisionService[T]) ListRevisionsByAuthor(author string, offset, limit int, visible func(*Revision[T]) bool) ([]*Revision[T], int, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	return s.Repository.ListRevisionsByAuthor(author, offset, limit, visible)
+}
+
+func (s *RevisionService[T]) AddRevision(recordID int, record T, author, comment string, changeSize int) error {
+	e := &Revision[T]{
+		RecordID:   recordID,
+		Record:     record,
+		Author:     author,
+		Comment:    comment,
+		ChangeSize: changeSize,
+	}
 	e.InsertDate = time.Now()
 	return s.Repository.AddRevision(e)
 }
+
+func (s *RevisionService[T]) DeleteRevisions(recordID int) error {
+	return s.Repository.DeleteRevisions(recordID)
+}
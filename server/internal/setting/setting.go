@@ -1,11 +1,13 @@
 package setting
 
 type Setting struct {
-	SiteName        string `json:"site_name"`
-	SiteURL         string `json:"site_url"`
-	Logo            string `json:"logo"`
-	Theme           string `json:"theme"`
-	Footer          string `json:"footer"`
-	Language        string `json:"language"`
-	IsSiteProtected bool   `json:"is_site_protected"`
+	SiteName           string `json:"site_name"`
+	SiteURL            string `json:"site_url"`
+	Logo               string `json:"logo"`
+	Theme              string `json:"theme"`
+	Footer             string `json:"footer"`
+	Language           string `json:"language"`
+	IsSiteProtected    bool   `json:"is_site_protected"`
+	TrashRetentionDays int    `json:"trash_retention_days"` // 0 keeps deleted pages until they are purged
+	IndexAttachments   bool   `json:"index_attachments"`    // search the text of PDF, TXT and Markdown uploads
 }
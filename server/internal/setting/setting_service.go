@@ -11,6 +11,8 @@ type SettingService struct {
 	DB            SettingRepository
 	Cache         *caching.SimpleCache[*Setting]
 	SecurityCache *caching.SimpleCache[*SecuritySetting]
+	// OnUpdated is called after the setting is saved, with the previous setting if it was cached.
+	OnUpdated func(previous *Setting, updated *Setting)
 }
 
 func (s *SettingService) Init(initial *Setting, initialSecurity *SecuritySetting) error {
@@ -44,6 +46,9 @@ func (s *SettingService) UpdateSetting(setting *Setting) error {
 			}
 		}
 		s.Cache.Set(setting)
+		if s.OnUpdated != nil {
+			s.OnUpdated(cached, setting)
+		}
 	}
 	return err
 }
@@ -64,6 +69,9 @@ func ValidateSetting(setting *Setting) error {
 			return errors.NewValidationError("SiteURL must start with http:// or https://", "SiteURL")
 		}
 	}
+	if setting.TrashRetentionDays < 0 {
+		return errors.NewValidationError("TrashRetentionDays must not be negative", "TrashRetentionDays")
+	}
 	return nil
 }
 
@@ -0,0 +1,60 @@
+package users
+
+import (
+	"time"
+
+	"wikigo/internal/roles"
+)
+
+type ApiTokenScope string
+
+const (
+	ApiTokenScopeRead ApiTokenScope = "read"
+	ApiTokenScopeEdit ApiTokenScope = "edit"
+)
+
+// ApiToken is a personal access token for scripts. Only the hash of the token is stored;
+// the token itself is shown once, when it is created. The hash is left out of the JSON of
+// the token, the repository stores it separately.
+type ApiToken struct {
+	ID         int           `json:"id"`
+	UserName   string        `json:"username"`
+	Name       string        `json:"name" validate:"required,max=100"`
+	Scope      ApiTokenScope `json:"scope" validate:"required,oneof=read edit"`
+	Prefix     string        `json:"prefix"`
+	TokenHash  string        `json:"-"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ExpiresAt  time.Time     `json:"expiresAt"`
+	LastUsedAt *time.Time    `json:"lastUsedAt"`
+}
+
+func (e *ApiToken) GetValue(field string) string {
+	switch field {
+	case "UserName":
+		return e.UserName
+	case "TokenHash":
+		return e.TokenHash
+	}
+	return ""
+}
+
+func (e *ApiToken) GetID() int {
+	return e.ID
+}
+
+func (e *ApiToken) SetID(id int) {
+	e.ID = id
+}
+
+func (e *ApiToken) IsExpired() bool {
+	return !e.ExpiresAt.IsZero() && time.Now().After(e.ExpiresAt)
+}
+
+// Role returns the role granted by the token. A token never grants more than the role
+// of its user, and never admin.
+func (e *ApiToken) Role(userRole string) string {
+	if e.Scope == ApiTokenScopeEdit && (userRole == string(roles.Editor) || userRole == string(roles.Admin)) {
+		return string(roles.Editor)
+	}
+	return string(roles.Reader)
+}
@@ -0,0 +1,11 @@
+package users
+
+type ApiTokenRepository interface {
+	Init() error
+	GetByID(id int) (*ApiToken, error)
+	GetByTokenHash(hash string) (*ApiToken, error)
+	GetByUserName(username string) ([]*ApiToken, error)
+	CreateToken(token *ApiToken) error
+	UpdateToken(token *ApiToken) error
+	DeleteToken(id int) error
+}
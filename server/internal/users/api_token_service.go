@@ -0,0 +1,124 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"wikigo/internal/common/errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ApiTokenPrefix starts every personal access token, so they are told apart from JWTs
+// and easy to find when leaked.
+const ApiTokenPrefix = "wgo_"
+
+const (
+	maxApiTokensPerUser = 20
+	// lastUsedInterval limits how often the last use of a token is saved
+	lastUsedInterval = time.Minute
+)
+
+type ApiTokenService struct {
+	DB     ApiTokenRepository
+	UserDB UserRepository
+}
+
+// CreateToken creates a token for a user and returns it together with the token value,
+// which cannot be retrieved later.
+func (s *ApiTokenService) CreateToken(username string, name string, scope ApiTokenScope, expiresAt time.Time) (*ApiToken, string, error) {
+	token := &ApiToken{
+		UserName:  username,
+		Name:      strings.TrimSpace(name),
+		Scope:     scope,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	if err := validator.New().Struct(token); err != nil {
+		return nil, "", errors.NewValidationError("invalid token: "+err.Error(), "name")
+	}
+	if !expiresAt.After(token.CreatedAt) {
+		return nil, "", errors.NewValidationError("expiry must be in the future", "expiresAt")
+	}
+	existing, err := s.DB.GetByUserName(username)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(existing) >= maxApiTokensPerUser {
+		return nil, "", errors.NewValidationError("too many tokens, revoke unused tokens first", "name")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	value := ApiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	token.TokenHash = hashToken(value)
+	token.Prefix = value[:len(ApiTokenPrefix)+4]
+	if err := s.DB.CreateToken(token); err != nil {
+		return nil, "", err
+	}
+	return token, value, nil
+}
+
+// ListTokens lists the tokens of a user, newest first.
+func (s *ApiTokenService) ListTokens(username string) ([]*ApiToken, error) {
+	tokens, err := s.DB.GetByUserName(username)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID > tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// RevokeToken deletes a token of a user.
+func (s *ApiTokenService) RevokeToken(username string, id int) error {
+	token, err := s.DB.GetByID(id)
+	if err != nil || token == nil || token.UserName != username {
+		return errors.NotFound("token not found")
+	}
+	return s.DB.DeleteToken(id)
+}
+
+// Authenticate returns the token and its user. Expired tokens and tokens of locked out
+// users are rejected.
+func (s *ApiTokenService) Authenticate(value string) (*ApiToken, *User, error) {
+	if !strings.HasPrefix(value, ApiTokenPrefix) {
+		return nil, nil, &UnauthorizedError{"invalid token"}
+	}
+	token, err := s.DB.GetByTokenHash(hashToken(value))
+	if err != nil {
+		return nil, nil, err
+	}
+	if token == nil || token.IsExpired() {
+		return nil, nil, &UnauthorizedError{"invalid token"}
+	}
+	user, err := s.UserDB.GetUserByUserName(token.UserName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.IsLockedOut {
+		return nil, nil, &UnauthorizedError{"invalid token"}
+	}
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastUsedInterval {
+		token.LastUsedAt = &now
+		if err := s.DB.UpdateToken(token); err != nil {
+			log.Printf("Failed to update token: %v\n", err)
+		}
+	}
+	return token, user, nil
+}
+
+func hashToken(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
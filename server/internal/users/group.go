@@ -0,0 +1,40 @@
+package users
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Group is a named set of users. Page ACLs and other permissions can target a group
+// instead of each of its members.
+type Group struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name" validate:"required,max=50"`
+	Description string    `json:"description" validate:"max=200"`
+	Members     []string  `json:"members"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (e *Group) GetValue(field string) string {
+	switch field {
+	case "Name":
+		return strings.ToLower(e.Name)
+	}
+	return ""
+}
+
+func (e *Group) GetID() int {
+	return e.ID
+}
+
+func (e *Group) SetID(id int) {
+	e.ID = id
+}
+
+func (e *Group) HasMember(username string) bool {
+	return slices.ContainsFunc(e.Members, func(member string) bool {
+		return strings.EqualFold(member, username)
+	})
+}
@@ -0,0 +1,11 @@
+package users
+
+type GroupRepository interface {
+	Init() error
+	GetGroupByID(id int) (*Group, error)
+	GetGroupByName(name string) (*Group, error)
+	ListAll() ([]*Group, error)
+	CreateGroup(group *Group) error
+	UpdateGroup(group *Group) error
+	DeleteGroup(id int) error
+}
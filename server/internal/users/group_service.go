@@ -0,0 +1,191 @@
+package users
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"wikigo/internal/common/errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var groupNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+type GroupService struct {
+	DB     GroupRepository
+	UserDB UserRepository
+}
+
+func (s *GroupService) ListGroups() ([]*Group, error) {
+	groups, err := s.DB.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
+	return groups, nil
+}
+
+func (s *GroupService) GetGroup(id int) (*Group, error) {
+	group, err := s.DB.GetGroupByID(id)
+	if err != nil || group == nil {
+		return nil, errors.NotFound("group not found")
+	}
+	return group, nil
+}
+
+// GetUserGroups returns the names of the groups a user is a member of.
+func (s *GroupService) GetUserGroups(username string) ([]string, error) {
+	groups, err := s.DB.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, group := range groups {
+		if group.HasMember(username) {
+			names = append(names, group.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *GroupService) CreateGroup(group *Group) error {
+	// The ID is cleared first, so the name check does not skip a group whose ID was sent
+	group.ID = 0
+	if err := s.validateGroup(group); err != nil {
+		return err
+	}
+	members, err := s.normalizeMembers(group.Members)
+	if err != nil {
+		return err
+	}
+	group.Members = members
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = group.CreatedAt
+	return s.DB.CreateGroup(group)
+}
+
+func (s *GroupService) UpdateGroup(group *Group) error {
+	existing, err := s.GetGroup(group.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateGroup(group); err != nil {
+		return err
+	}
+	members, err := s.normalizeMembers(group.Members)
+	if err != nil {
+		return err
+	}
+	existing.Name = group.Name
+	existing.Description = group.Description
+	existing.Members = members
+	existing.UpdatedAt = time.Now()
+	*group = *existing
+	return s.DB.UpdateGroup(existing)
+}
+
+func (s *GroupService) DeleteGroup(id int) error {
+	if _, err := s.GetGroup(id); err != nil {
+		return err
+	}
+	return s.DB.DeleteGroup(id)
+}
+
+func (s *GroupService) AddMember(id int, username string) (*Group, error) {
+	group, err := s.GetGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.normalizeMembers(append(group.Members, username))
+	if err != nil {
+		return nil, err
+	}
+	group.Members = members
+	group.UpdatedAt = time.Now()
+	return group, s.DB.UpdateGroup(group)
+}
+
+func (s *GroupService) RemoveMember(id int, username string) (*Group, error) {
+	group, err := s.GetGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	if !group.HasMember(username) {
+		return nil, errors.NotFound("user is not a member of the group")
+	}
+	group.Members = slices.DeleteFunc(group.Members, func(member string) bool {
+		return strings.EqualFold(member, username)
+	})
+	group.UpdatedAt = time.Now()
+	return group, s.DB.UpdateGroup(group)
+}
+
+// RenameMember keeps the memberships of a user whose user name changed.
+func (s *GroupService) RenameMember(oldName, newName string) error {
+	groups, err := s.DB.ListAll()
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if !group.HasMember(oldName) {
+			continue
+		}
+		for i, member := range group.Members {
+			if strings.EqualFold(member, oldName) {
+				group.Members[i] = newName
+			}
+		}
+		group.UpdatedAt = time.Now()
+		if err := s.DB.UpdateGroup(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GroupService) validateGroup(group *Group) error {
+	group.Name = strings.TrimSpace(group.Name)
+	if err := validator.New().Struct(group); err != nil {
+		return errors.NewValidationError("invalid group: "+err.Error(), "name")
+	}
+	if !groupNameRegexp.MatchString(group.Name) {
+		return errors.NewValidationError("group name can only contain letters, digits, - and _", "name")
+	}
+	existing, err := s.DB.GetGroupByName(group.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != group.ID {
+		return errors.NewValidationError("group name already exists", "name")
+	}
+	return nil
+}
+
+// normalizeMembers checks that the members are existing users and removes duplicates.
+func (s *GroupService) normalizeMembers(members []string) ([]string, error) {
+	result := make([]string, 0, len(members))
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		user, err := s.UserDB.GetUserByUserName(member)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.NewValidationError("user not found: "+member, "members")
+		}
+		if !slices.Contains(result, user.UserName) {
+			result = append(result, user.UserName)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
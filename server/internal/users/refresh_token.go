@@ -0,0 +1,35 @@
+package users
+
+import (
+	"time"
+)
+
+// RefreshToken renews the access token of a session. Each refresh token can be used once
+// and is replaced by a new one; the tokens of a session form a family that is revoked as
+// a whole when a used token shows up again.
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	SessionID string     `json:"sessionId"`
+	TokenHash string     `json:"tokenHash"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
+
+func (e *RefreshToken) GetValue(field string) string {
+	switch field {
+	case "SessionID":
+		return e.SessionID
+	case "TokenHash":
+		return e.TokenHash
+	}
+	return ""
+}
+
+func (e *RefreshToken) GetID() int {
+	return e.ID
+}
+
+func (e *RefreshToken) SetID(id int) {
+	e.ID = id
+}
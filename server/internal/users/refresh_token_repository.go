@@ -0,0 +1,10 @@
+package users
+
+type RefreshTokenRepository interface {
+	Init() error
+	GetByTokenHash(hash string) (*RefreshToken, error)
+	GetBySessionID(sessionID string) ([]*RefreshToken, error)
+	CreateToken(token *RefreshToken) error
+	UpdateToken(token *RefreshToken) error
+	DeleteToken(id int) error
+}
@@ -0,0 +1,41 @@
+package users
+
+import (
+	"time"
+)
+
+// UserSession is a login. The session ID is the jti claim of the auth token, so a token
+// stops working as soon as its session is revoked.
+type UserSession struct {
+	ID         int        `json:"id"`
+	SessionID  string     `json:"sessionId"`
+	UserName   string     `json:"username"`
+	IPAddress  string     `json:"ipAddress"`
+	UserAgent  string     `json:"userAgent"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+func (e *UserSession) GetValue(field string) string {
+	switch field {
+	case "SessionID":
+		return e.SessionID
+	case "UserName":
+		return e.UserName
+	}
+	return ""
+}
+
+func (e *UserSession) GetID() int {
+	return e.ID
+}
+
+func (e *UserSession) SetID(id int) {
+	e.ID = id
+}
+
+func (e *UserSession) IsActive() bool {
+	return e.RevokedAt == nil && time.Now().Before(e.ExpiresAt)
+}
@@ -0,0 +1,11 @@
+package users
+
+type UserSessionRepository interface {
+	Init() error
+	GetByID(id int) (*UserSession, error)
+	GetBySessionID(sessionID string) (*UserSession, error)
+	GetByUserName(username string) ([]*UserSession, error)
+	CreateSession(session *UserSession) error
+	UpdateSession(session *UserSession) error
+	DeleteSession(id int) error
+}
@@ -0,0 +1,264 @@
+package users
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"wikigo/internal/common/errors"
+)
+
+const (
+	// lastSeenInterval limits how often the last activity of a session is saved
+	lastSeenInterval = time.Minute
+	// RefreshTokenLifetime is how long a session can stay idle before the user has to log
+	// in again
+	RefreshTokenLifetime = 7 * 24 * time.Hour
+	// refreshReuseGrace tolerates a used refresh token for a moment, as concurrent
+	// requests of the same browser can send the token that was just replaced
+	refreshReuseGrace = 10 * time.Second
+)
+
+// ErrRefreshTokenReplaced is returned for a refresh token used again within the grace
+// period. The token sent in the response of the first use is still valid.
+var ErrRefreshTokenReplaced = &UnauthorizedError{"refresh token already used"}
+
+type UserSessionService struct {
+	DB        UserSessionRepository
+	RefreshDB RefreshTokenRepository
+	// refreshMu serializes rotations, so a refresh token cannot be used by two requests
+	// that both read it before either marks it used
+	refreshMu sync.Mutex
+}
+
+// CreateSession starts a session for a login. Expired and revoked sessions of the user
+// are removed at the same time.
+func (s *UserSessionService) CreateSession(username string, expiresAt time.Time, ipAddress string, userAgent string) (*UserSession, error) {
+	if err := s.deleteInactiveSessions(username); err != nil {
+		return nil, err
+	}
+	id := make([]byte, 18)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if len(userAgent) > 300 {
+		userAgent = userAgent[:300]
+	}
+	now := time.Now()
+	session := &UserSession{
+		SessionID:  base64.RawURLEncoding.EncodeToString(id),
+		UserName:   username,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		ExpiresAt:  expiresAt,
+		LastSeenAt: now,
+	}
+	if err := s.DB.CreateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ValidateSession reports whether the session of a token is still active.
+func (s *UserSessionService) ValidateSession(sessionID string, username string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	session, err := s.DB.GetBySessionID(sessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || session.UserName != username || !session.IsActive() {
+		return false, nil
+	}
+	if now := time.Now(); now.Sub(session.LastSeenAt) > lastSeenInterval {
+		session.LastSeenAt = now
+		if err := s.DB.UpdateSession(session); err != nil {
+			log.Printf("Failed to update session: %v\n", err)
+		}
+	}
+	return true, nil
+}
+
+// ListSessions lists the active sessions of a user, most recently used first.
+func (s *UserSessionService) ListSessions(username string) ([]*UserSession, error) {
+	sessions, err := s.DB.GetByUserName(username)
+	if err != nil {
+		return nil, err
+	}
+	active := make([]*UserSession, 0, len(sessions))
+	for _, session := range sessions {
+		if session.IsActive() {
+			active = append(active, session)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].LastSeenAt.After(active[j].LastSeenAt)
+	})
+	return active, nil
+}
+
+// RevokeSession ends a session of a user.
+func (s *UserSessionService) RevokeSession(username string, id int) error {
+	session, err := s.DB.GetByID(id)
+	if err != nil || session == nil || session.UserName != username || !session.IsActive() {
+		return errors.NotFound("session not found")
+	}
+	return s.revoke(session)
+}
+
+// RevokeSessionByID ends the session of a token, e.g. when the user logs out.
+func (s *UserSessionService) RevokeSessionByID(sessionID string) error {
+	session, err := s.DB.GetBySessionID(sessionID)
+	if err != nil || session == nil || !session.IsActive() {
+		return err
+	}
+	return s.revoke(session)
+}
+
+// RevokeUserSessions ends all sessions of a user and returns how many were active.
+func (s *UserSessionService) RevokeUserSessions(username string) (int, error) {
+	sessions, err := s.ListSessions(username)
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		if err := s.revoke(session); err != nil {
+			return 0, err
+		}
+	}
+	return len(sessions), nil
+}
+
+func (s *UserSessionService) revoke(session *UserSession) error {
+	now := time.Now()
+	session.RevokedAt = &now
+	return s.DB.UpdateSession(session)
+}
+
+func (s *UserSessionService) deleteInactiveSessions(username string) error {
+	sessions, err := s.DB.GetByUserName(username)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if !session.IsActive() {
+			if err := s.deleteRefreshTokens(session.SessionID, false); err != nil {
+				return err
+			}
+			if err := s.DB.DeleteSession(session.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IssueRefreshToken creates a refresh token for a session and returns its value.
+func (s *UserSessionService) IssueRefreshToken(session *UserSession) (string, time.Time, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", time.Time{}, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(secret)
+	now := time.Now()
+	token := &RefreshToken{
+		SessionID: session.SessionID,
+		TokenHash: hashToken(value),
+		CreatedAt: now,
+		ExpiresAt: now.Add(RefreshTokenLifetime),
+	}
+	if token.ExpiresAt.After(session.ExpiresAt) {
+		token.ExpiresAt = session.ExpiresAt
+	}
+	if err := s.RefreshDB.CreateToken(token); err != nil {
+		return "", time.Time{}, err
+	}
+	return value, token.ExpiresAt, nil
+}
+
+// RotateRefreshToken replaces a refresh token with a new one and returns its session.
+// A refresh token used a second time means it was stolen, so its session is revoked
+// and every token of it stops working.
+func (s *UserSessionService) RotateRefreshToken(value string) (*UserSession, string, time.Time, error) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	invalid := &UnauthorizedError{"invalid refresh token"}
+	token, err := s.RefreshDB.GetByTokenHash(hashToken(value))
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if token == nil {
+		return nil, "", time.Time{}, invalid
+	}
+	session, err := s.DB.GetBySessionID(token.SessionID)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if session == nil || !session.IsActive() {
+		return nil, "", time.Time{}, invalid
+	}
+	now := time.Now()
+	if token.UsedAt != nil {
+		if now.Sub(*token.UsedAt) > refreshReuseGrace {
+			log.Printf("Refresh token reused, revoking session %d of %s\n", session.ID, session.UserName)
+			if err := s.revoke(session); err != nil {
+				return nil, "", time.Time{}, err
+			}
+			return nil, "", time.Time{}, invalid
+		}
+		return nil, "", time.Time{}, ErrRefreshTokenReplaced
+	}
+	if now.After(token.ExpiresAt) {
+		return nil, "", time.Time{}, invalid
+	}
+	token.UsedAt = &now
+	if err := s.RefreshDB.UpdateToken(token); err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if err := s.deleteRefreshTokens(session.SessionID, true); err != nil {
+		return nil, "", time.Time{}, err
+	}
+	newValue, expiresAt, err := s.IssueRefreshToken(session)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	session.LastSeenAt = now
+	if err := s.DB.UpdateSession(session); err != nil {
+		return nil, "", time.Time{}, err
+	}
+	return session, newValue, expiresAt, nil
+}
+
+// RevokeSessionByRefreshToken ends the session of a refresh token, e.g. when the user
+// logs out after the access token expired.
+func (s *UserSessionService) RevokeSessionByRefreshToken(value string) error {
+	token, err := s.RefreshDB.GetByTokenHash(hashToken(value))
+	if err != nil || token == nil {
+		return err
+	}
+	return s.RevokeSessionByID(token.SessionID)
+}
+
+// deleteRefreshTokens removes the refresh tokens of a session. Used tokens are kept
+// until they expire, so their reuse is still detected.
+func (s *UserSessionService) deleteRefreshTokens(sessionID string, expiredOnly bool) error {
+	tokens, err := s.RefreshDB.GetBySessionID(sessionID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, token := range tokens {
+		if expiredOnly && now.Before(token.ExpiresAt) {
+			continue
+		}
+		if err := s.RefreshDB.DeleteToken(token.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package users
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySessionRepository and memoryRefreshTokenRepository keep copies of the entities,
+// like the file database, so changes are only seen after they are saved.
+type memorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[int]UserSession
+	nextID   int
+}
+
+func (r *memorySessionRepository) Init() error {
+	r.sessions = make(map[int]UserSession)
+	return nil
+}
+
+func (r *memorySessionRepository) GetByID(id int) (*UserSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[id]; ok {
+		return &session, nil
+	}
+	return nil, nil
+}
+
+func (r *memorySessionRepository) GetBySessionID(sessionID string) (*UserSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		if session.SessionID == sessionID {
+			return &session, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memorySessionRepository) GetByUserName(username string) ([]*UserSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []*UserSession
+	for _, session := range r.sessions {
+		if session.UserName == username {
+			sessions = append(sessions, &session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memorySessionRepository) CreateSession(session *UserSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	session.ID = r.nextID
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *memorySessionRepository) UpdateSession(session *UserSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *memorySessionRepository) DeleteSession(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+	return nil
+}
+
+type memoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[int]RefreshToken
+	nextID int
+	// readDelay holds back the result of a lookup, widening the window between reading
+	// a token and saving it
+	readDelay time.Duration
+}
+
+func (r *memoryRefreshTokenRepository) Init() error {
+	r.tokens = make(map[int]RefreshToken)
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) GetByTokenHash(hash string) (*RefreshToken, error) {
+	defer time.Sleep(r.readDelay)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRefreshTokenRepository) GetBySessionID(sessionID string) ([]*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tokens []*RefreshToken
+	for _, token := range r.tokens {
+		if token.SessionID == sessionID {
+			tokens = append(tokens, &token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *memoryRefreshTokenRepository) CreateToken(token *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	token.ID = r.nextID
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) UpdateToken(token *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) DeleteToken(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tokens, id)
+	return nil
+}
+
+func newTestSession(t *testing.T) (*UserSessionService, *memoryRefreshTokenRepository, *UserSession, string) {
+	t.Helper()
+	sessions := &memorySessionRepository{}
+	tokens := &memoryRefreshTokenRepository{}
+	sessions.Init()
+	tokens.Init()
+	service := &UserSessionService{DB: sessions, RefreshDB: tokens}
+	session, err := service.CreateSession("alice", time.Now().Add(time.Hour), "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, _, err := service.IssueRefreshToken(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service, tokens, session, value
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	service, tokens, session, first := newTestSession(t)
+	rotated, second, _, err := service.RotateRefreshToken(first)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %v", err)
+	}
+	if rotated.SessionID != session.SessionID || second == first {
+		t.Fatalf("RotateRefreshToken() = %q, %q, expected a new token of session %q", rotated.SessionID, second, session.SessionID)
+	}
+	if used, _ := tokens.GetByTokenHash(hashToken(first)); used == nil || used.UsedAt == nil {
+		t.Error("RotateRefreshToken() did not mark the old token used")
+	}
+	if _, _, _, err := service.RotateRefreshToken(second); err != nil {
+		t.Errorf("RotateRefreshToken() of the new token error = %v", err)
+	}
+}
+
+func TestRotateRefreshTokenReuseWithinGrace(t *testing.T) {
+	service, _, session, first := newTestSession(t)
+	if _, _, _, err := service.RotateRefreshToken(first); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := service.RotateRefreshToken(first); err != ErrRefreshTokenReplaced {
+		t.Errorf("RotateRefreshToken() error = %v, expected %v", err, ErrRefreshTokenReplaced)
+	}
+	if active, _ := service.ValidateSession(session.SessionID, "alice"); !active {
+		t.Error("reuse within the grace period revoked the session")
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokesSession(t *testing.T) {
+	service, tokens, session, first := newTestSession(t)
+	_, second, _, err := service.RotateRefreshToken(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, _ := tokens.GetByTokenHash(hashToken(first))
+	usedAt := time.Now().Add(-refreshReuseGrace - time.Second)
+	used.UsedAt = &usedAt
+	tokens.UpdateToken(used)
+
+	_, _, _, err = service.RotateRefreshToken(first)
+	if _, ok := err.(*UnauthorizedError); !ok || err == ErrRefreshTokenReplaced {
+		t.Fatalf("RotateRefreshToken() error = %v, expected an invalid token", err)
+	}
+	if active, _ := service.ValidateSession(session.SessionID, "alice"); active {
+		t.Error("reuse of a refresh token did not revoke the session")
+	}
+	if _, _, _, err := service.RotateRefreshToken(second); err == nil {
+		t.Error("the latest token of a revoked session still works")
+	}
+}
+
+func TestRotateRefreshTokenConcurrently(t *testing.T) {
+	service, tokens, _, first := newTestSession(t)
+	tokens.readDelay = 5 * time.Millisecond
+	const requests = 10
+	results := make(chan error, requests)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, _, _, err := service.RotateRefreshToken(first)
+			results <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+	rotated := 0
+	for err := range results {
+		switch err {
+		case nil:
+			rotated++
+		case ErrRefreshTokenReplaced:
+		default:
+			t.Errorf("RotateRefreshToken() error = %v", err)
+		}
+	}
+	if rotated != 1 {
+		t.Errorf("token rotated %d times, expected once", rotated)
+	}
+}